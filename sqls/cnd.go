@@ -1,17 +1,37 @@
 package sqls
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/YspCoder/simple/common/strs"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Cnd struct {
 	SelectCols []string     // 要查询的字段，如果为空，表示查询所有字段
+	Joins      []JoinClause // 联表
 	Params     []ParamPair  // 参数
+	GroupBys   []string     // 分组字段
+	Havings    []ParamPair  // 分组过滤条件
 	Orders     []OrderByCol // 排序
 	Paging     *Paging      // 分页
+
+	CursorPaging *CursorPaging // 游标分页，设置后忽略 Paging
+	QueryTimeout time.Duration // 查询超时时间，0 表示不限制
+
+	AllowedCols map[string]bool // 允许使用的列，为空表示不限制
+	Error       error           // 构建条件时产生的错误，例如非法列名，执行查询时返回
+
+	Global bool // 没有查询条件时是否允许执行 Update、Delete，见 AllowGlobal
+
+	SelectDistinct bool            // 是否去重，见 Distinct
+	Lock           *clause.Locking // 行锁，见 ForUpdate、ForShare
+	IndexHintList  []IndexHint     // 索引提示，见 UseIndex
 }
 
 type ParamPair struct {
@@ -19,10 +39,22 @@ type ParamPair struct {
 	Args  []interface{} // 参数
 }
 
+// JoinClause 联表信息
+type JoinClause struct {
+	Type  string        // 联表类型：INNER、LEFT、RIGHT
+	Table string        // 联表的表名
+	Alias string        // 表别名，可以为空
+	On    string        // 联表条件，例如：u.id = orders.user_id
+	Args  []interface{} // 联表条件参数
+}
+
 // OrderByCol 排序信息
 type OrderByCol struct {
-	Column string // 排序字段
-	Asc    bool   // 是否正序
+	Column string        // 排序字段，列名在执行查询时按方言加引号，已加引号的列名或表达式原样使用
+	Asc    bool          // 是否正序
+	Args   []interface{} // 排序表达式的参数
+
+	expr dialectExpr // 依赖方言的排序表达式，例如全文检索相关度，设置后忽略 Column、Args
 }
 
 func NewCnd() *Cnd {
@@ -30,107 +62,289 @@ func NewCnd() *Cnd {
 }
 
 func (s *Cnd) Cols(selectCols ...string) *Cnd {
-	if len(selectCols) > 0 {
-		s.SelectCols = append(s.SelectCols, selectCols...)
+	for _, col := range selectCols {
+		if err := s.checkColumn(col); err != nil {
+			s.AddError(err)
+			continue
+		}
+		s.SelectCols = append(s.SelectCols, col)
 	}
 	return s
 }
 
+// InnerJoin 内联表，例如：InnerJoin("users", "u", "u.id = orders.user_id")
+func (s *Cnd) InnerJoin(table, alias, on string, args ...interface{}) *Cnd {
+	return s.join("INNER", table, alias, on, args)
+}
+
+// LeftJoin 左联表
+func (s *Cnd) LeftJoin(table, alias, on string, args ...interface{}) *Cnd {
+	return s.join("LEFT", table, alias, on, args)
+}
+
+// RightJoin 右联表
+func (s *Cnd) RightJoin(table, alias, on string, args ...interface{}) *Cnd {
+	return s.join("RIGHT", table, alias, on, args)
+}
+
+func (s *Cnd) join(joinType, table, alias, on string, args []interface{}) *Cnd {
+	if err := ValidateIdentifier(table); err != nil {
+		return s.AddError(err)
+	}
+	if strs.IsNotBlank(alias) && !isIdentifierPart(alias) {
+		return s.AddError(fmt.Errorf("%w: %q", ErrInvalidColumn, alias))
+	}
+	s.Joins = append(s.Joins, JoinClause{Type: joinType, Table: table, Alias: alias, On: on, Args: args})
+	return s
+}
+
 func (s *Cnd) Eq(column string, args ...interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" = ?", args)
+	if col, ok := s.column(column); ok {
+		s.Where("? = ?", columnExpr(col), args)
+	}
 	return s
 }
 
 func (s *Cnd) NotEq(column string, args ...interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" <> ?", args)
+	if col, ok := s.column(column); ok {
+		s.Where("? <> ?", columnExpr(col), args)
+	}
 	return s
 }
 
 func (s *Cnd) Gt(column string, args ...interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" > ?", args)
+	if col, ok := s.column(column); ok {
+		s.Where("? > ?", columnExpr(col), args)
+	}
 	return s
 }
 
 func (s *Cnd) Gte(column string, args ...interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" >= ?", args)
+	if col, ok := s.column(column); ok {
+		s.Where("? >= ?", columnExpr(col), args)
+	}
 	return s
 }
 
 func (s *Cnd) Lt(column string, args ...interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" < ?", args)
+	if col, ok := s.column(column); ok {
+		s.Where("? < ?", columnExpr(col), args)
+	}
 	return s
 }
 
 func (s *Cnd) Lte(column string, args ...interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" <= ?", args)
+	if col, ok := s.column(column); ok {
+		s.Where("? <= ?", columnExpr(col), args)
+	}
 	return s
 }
 
+// Like 包含 str，str 中的通配符按字面匹配
 func (s *Cnd) Like(column string, str string) *Cnd {
-	s.Where(KeywordWrap(column)+" LIKE ?", "%"+str+"%")
-	return s
+	return s.like(column, "%"+EscapeLike(str)+"%", false)
 }
 
+// Starting 以 str 开头，str 中的通配符按字面匹配
 func (s *Cnd) Starting(column string, str string) *Cnd {
-	s.Where(KeywordWrap(column)+" LIKE ?", str+"%")
-	return s
+	return s.like(column, EscapeLike(str)+"%", false)
 }
 
+// Ending 以 str 结尾，str 中的通配符按字面匹配
 func (s *Cnd) Ending(column string, str string) *Cnd {
-	s.Where(KeywordWrap(column)+" LIKE ?", "%"+str)
-	return s
+	return s.like(column, "%"+EscapeLike(str), false)
+}
+
+// ILike 包含 str，大小写不敏感：Postgres 使用 ILIKE，其他数据库使用 LOWER()
+func (s *Cnd) ILike(column string, str string) *Cnd {
+	return s.like(column, "%"+EscapeLike(str)+"%", true)
+}
+
+// IStarting 以 str 开头，大小写不敏感，见 ILike
+func (s *Cnd) IStarting(column string, str string) *Cnd {
+	return s.like(column, EscapeLike(str)+"%", true)
+}
+
+// IEnding 以 str 结尾，大小写不敏感，见 ILike
+func (s *Cnd) IEnding(column string, str string) *Cnd {
+	return s.like(column, "%"+EscapeLike(str), true)
+}
+
+func (s *Cnd) like(column, pattern string, ignoreCase bool) *Cnd {
+	return s.dialectWhere(column, func(d Dialect, col string) (string, []interface{}) {
+		return d.Like(col, ignoreCase), []interface{}{pattern}
+	})
 }
 
 func (s *Cnd) In(column string, params interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" in (?) ", params)
+	if col, ok := s.column(column); ok {
+		s.Where("? in (?) ", columnExpr(col), params)
+	}
 	return s
 }
 
 func (s *Cnd) NotIn(column string, params interface{}) *Cnd {
-	s.Where(KeywordWrap(column)+" not in (?) ", params)
+	if col, ok := s.column(column); ok {
+		s.Where("? not in (?) ", columnExpr(col), params)
+	}
 	return s
 }
 
-// 兼容 MySQL + Postgres：匹配逗号分隔的 text 列中是否包含某个 token
+// FindInSet 匹配逗号分隔的字符串列中是否包含某个值，具体语法由执行查询的 db 的方言决定
 func (s *Cnd) FindInSet(column string, value interface{}) *Cnd {
-	// CONCAT(',', col, ',') LIKE CONCAT('%,', value, ',%')
-	s.Where(fmt.Sprintf("CONCAT(',', %s, ',') LIKE CONCAT('%,', ?, ',%%')", KeywordWrap(column)), value)
-	return s
+	return s.dialectWhere(column, func(d Dialect, col string) (string, []interface{}) {
+		return d.FindInSet(col), []interface{}{value}
+	})
 }
 
 func (s *Cnd) NotFindInSet(column string, value interface{}) *Cnd {
-	s.Where(fmt.Sprintf("CONCAT(',', %s, ',') NOT LIKE CONCAT('%,', ?, ',%%')", KeywordWrap(column)), value)
+	return s.dialectWhere(column, func(d Dialect, col string) (string, []interface{}) {
+		return "NOT (" + d.FindInSet(col) + ")", []interface{}{value}
+	})
+}
+
+// ArrayContainsAll 数组列是否包含全部 values
+func (s *Cnd) ArrayContainsAll(column string, values []string) *Cnd {
+	return s.array(column, ArrayContainsAll, values)
+}
+
+// ArrayOverlaps 数组列是否与 values 有交集，等价于 contains any
+func (s *Cnd) ArrayOverlaps(column string, values []string) *Cnd {
+	return s.array(column, ArrayOverlaps, values)
+}
+
+// ArrayContainedBy 数组列是否被 values 包含
+func (s *Cnd) ArrayContainedBy(column string, values []string) *Cnd {
+	return s.array(column, ArrayContainedBy, values)
+}
+
+// ArrayAnyEqual 单值是否在数组列中
+func (s *Cnd) ArrayAnyEqual(column string, value interface{}) *Cnd {
+	return s.array(column, ArrayAnyEqual, value)
+}
+
+// ArrayNotInAll 单值是否不在数组列中
+func (s *Cnd) ArrayNotInAll(column string, value interface{}) *Cnd {
+	return s.array(column, ArrayNotInAll, value)
+}
+
+func (s *Cnd) array(column string, op ArrayOp, value interface{}) *Cnd {
+	return s.dialectWhere(column, func(d Dialect, col string) (string, []interface{}) {
+		query, arg := d.Array(col, op, value)
+		return query, []interface{}{arg}
+	})
+}
+
+// Match 全文检索，columns 中任意列匹配 query 即满足条件，query 为空白时忽略该条件。
+// MySQL 使用 MATCH ... AGAINST（需要 FULLTEXT 索引），Postgres 使用 to_tsvector @@ plainto_tsquery，
+// SQLite 使用 FTS5 MATCH（列名需要带上虚拟表名，例如 posts.title）
+func (s *Cnd) Match(columns []string, query string) *Cnd {
+	if s.checkMatch(columns, query) {
+		s.Where("?", matchExpr(columns, query, false))
+	}
 	return s
 }
 
-// 数组列 col 是否“包含全部 values”（@>）
-func (s *Cnd) PgArrayContainsAll(column string, values []string) *Cnd {
-	s.Where(KeywordWrap(column)+" @> ?::text[]", values)
+// OrderByMatch 按全文检索相关度从高到低排序，通常与相同参数的 Match 一起使用，不支持游标分页，
+// 方言不支持相关度时忽略
+func (s *Cnd) OrderByMatch(columns []string, query string) *Cnd {
+	if s.checkMatch(columns, query) {
+		s.Orders = append(s.Orders, OrderByCol{Asc: false, expr: matchExpr(columns, query, true)})
+	}
 	return s
 }
 
-// 数组列 col 是否“与 values 有交集”（&&）=> 等价于 contains any
+func (s *Cnd) checkMatch(columns []string, query string) bool {
+	if strings.TrimSpace(query) == "" {
+		return false
+	}
+	if len(columns) == 0 {
+		s.AddError(fmt.Errorf("%w: full-text search requires at least one column", ErrInvalidColumn))
+		return false
+	}
+	for _, column := range columns {
+		if err := s.checkColumn(column); err != nil {
+			s.AddError(err)
+			return false
+		}
+	}
+	return true
+}
+
+// matchExpr 全文检索条件，score 为 true 时为相关度表达式，方言不支持相关度时为空
+func matchExpr(columns []string, query string, score bool) dialectExpr {
+	return func(d Dialect) (string, []interface{}, error) {
+		cond, scoreExpr, arg, err := d.Match(columns, query)
+		if err != nil {
+			return "", nil, err
+		}
+		if score {
+			cond = scoreExpr
+		}
+		if cond == "" {
+			return "", nil, nil
+		}
+		return cond, []interface{}{arg}, nil
+	}
+}
+
+// JsonEq JSON 列 path 上的值等于 value，path 以 . 分隔，纯数字表示数组下标，例如：address.city、tags.0。
+// 字符串 value 以文本形式比较
+func (s *Cnd) JsonEq(column, path string, value interface{}) *Cnd {
+	return s.json(column, JsonEq, path, value)
+}
+
+// JsonContains JSON 列 path 上的值包含 value：对象包含 value 的全部键值，数组包含 value 的全部元素，path 为空表示整列
+func (s *Cnd) JsonContains(column, path string, value interface{}) *Cnd {
+	return s.json(column, JsonContains, path, value)
+}
+
+// JsonHasKey JSON 列中存在 path
+func (s *Cnd) JsonHasKey(column, path string) *Cnd {
+	return s.json(column, JsonHasKey, path, nil)
+}
+
+// JsonArrayContains JSON 列 path 上的数组包含单个值 value
+func (s *Cnd) JsonArrayContains(column, path string, value interface{}) *Cnd {
+	return s.json(column, JsonArrayContains, path, value)
+}
+
+func (s *Cnd) json(column string, op JsonOp, path string, value interface{}) *Cnd {
+	keys, err := ParseJsonPath(path)
+	if err != nil {
+		return s.AddError(err)
+	}
+	if op == JsonHasKey && len(keys) == 0 {
+		return s.AddError(fmt.Errorf("%w: %q", ErrInvalidJsonPath, path))
+	}
+	return s.dialectWhere(column, func(d Dialect, col string) (string, []interface{}) {
+		return d.Json(col, op, keys, value)
+	})
+}
+
+// Deprecated: 使用 ArrayContainsAll
+func (s *Cnd) PgArrayContainsAll(column string, values []string) *Cnd {
+	return s.ArrayContainsAll(column, values)
+}
+
+// Deprecated: 使用 ArrayOverlaps
 func (s *Cnd) PgArrayOverlaps(column string, values []string) *Cnd {
-	s.Where(KeywordWrap(column)+" && ?::text[]", values)
-	return s
+	return s.ArrayOverlaps(column, values)
 }
 
-// 数组列 col 是否“被 values 包含”（<@）
+// Deprecated: 使用 ArrayContainedBy
 func (s *Cnd) PgArrayContainedBy(column string, values []string) *Cnd {
-	s.Where(KeywordWrap(column)+" <@ ?::text[]", values)
-	return s
+	return s.ArrayContainedBy(column, values)
 }
 
-// 单值是否在数组列中（= ANY(col)）
+// Deprecated: 使用 ArrayAnyEqual
 func (s *Cnd) PgAnyEqual(column string, value interface{}) *Cnd {
-	s.Where("? = ANY("+KeywordWrap(column)+")", value)
-	return s
+	return s.ArrayAnyEqual(column, value)
 }
 
-// 单值不在数组列中（<> ALL(col)）
+// Deprecated: 使用 ArrayNotInAll
 func (s *Cnd) PgNotInAll(column string, value interface{}) *Cnd {
-	s.Where("? <> ALL("+KeywordWrap(column)+")", value)
-	return s
+	return s.ArrayNotInAll(column, value)
 }
 
 func (s *Cnd) Where(query string, args ...interface{}) *Cnd {
@@ -138,13 +352,153 @@ func (s *Cnd) Where(query string, args ...interface{}) *Cnd {
 	return s
 }
 
+// dialectWhere 添加依赖方言的条件，执行查询时才调用 build 生成，col 为按方言加了引号的列名
+func (s *Cnd) dialectWhere(column string, build func(d Dialect, col string) (string, []interface{})) *Cnd {
+	if col, ok := s.column(column); ok {
+		s.Where("?", dialectExpr(func(d Dialect) (string, []interface{}, error) {
+			query, args := build(d, wrapKeyword(d, col))
+			return query, args, nil
+		}))
+	}
+	return s
+}
+
+// And 添加一组 AND 条件：各子条件自身的 Params 以 AND 连接并加括号，子条件之间同样以 AND 连接
+func (s *Cnd) And(cnds ...*Cnd) *Cnd {
+	return s.group(" AND ", cnds)
+}
+
+// Or 添加一组 OR 条件：各子条件自身的 Params 以 AND 连接并加括号，子条件之间以 OR 连接
+// 例如：cnd.Or(NewCnd().Like("name", x), NewCnd().Like("phone", x)) => ((name LIKE ?) OR (phone LIKE ?))
+func (s *Cnd) Or(cnds ...*Cnd) *Cnd {
+	return s.group(" OR ", cnds)
+}
+
+// Not 对子条件取反：NOT (...)，cnd 为 nil 时忽略
+func (s *Cnd) Not(cnd *Cnd) *Cnd {
+	if cnd == nil {
+		return s
+	}
+	// 子条件中被忽略的非法条件会放宽查询范围，错误需要传递给外层
+	s.AddError(cnd.Error)
+	if query, args := cnd.whereClause(); query != "" {
+		s.Where("NOT "+query, args...)
+	}
+	return s
+}
+
+// AndFunc 同 And，子条件通过闭包构建
+func (s *Cnd) AndFunc(fns ...func(sub *Cnd)) *Cnd {
+	return s.And(subCnds(fns)...)
+}
+
+// OrFunc 同 Or，子条件通过闭包构建
+func (s *Cnd) OrFunc(fns ...func(sub *Cnd)) *Cnd {
+	return s.Or(subCnds(fns)...)
+}
+
+// NotFunc 同 Not，子条件通过闭包构建
+func (s *Cnd) NotFunc(fn func(sub *Cnd)) *Cnd {
+	return s.Not(subCnds([]func(sub *Cnd){fn})[0])
+}
+
+func (s *Cnd) group(sep string, cnds []*Cnd) *Cnd {
+	var (
+		queries []string
+		args    []interface{}
+	)
+	for _, cnd := range cnds {
+		if cnd == nil {
+			continue
+		}
+		s.AddError(cnd.Error)
+		if query, subArgs := cnd.whereClause(); query != "" {
+			queries = append(queries, query)
+			args = append(args, subArgs...)
+		}
+	}
+	if len(queries) == 0 {
+		return s
+	}
+	if len(queries) == 1 {
+		return s.Where(queries[0], args...)
+	}
+	return s.Where("("+strings.Join(queries, sep)+")", args...)
+}
+
+// whereClause 将 Params 以 AND 连接成一个带括号的条件语句，子条件的 SelectCols、Orders、Paging 会被忽略
+func (s *Cnd) whereClause() (string, []interface{}) {
+	var (
+		queries []string
+		args    []interface{}
+	)
+	for _, param := range s.Params {
+		if strs.IsBlank(param.Query) {
+			continue
+		}
+		queries = append(queries, "("+param.Query+")")
+		args = append(args, param.Args...)
+	}
+	if len(queries) == 0 {
+		return "", nil
+	}
+	if len(queries) == 1 {
+		return queries[0], args
+	}
+	return "(" + strings.Join(queries, " AND ") + ")", args
+}
+
+func subCnds(fns []func(sub *Cnd)) []*Cnd {
+	cnds := make([]*Cnd, 0, len(fns))
+	for _, fn := range fns {
+		cnd := NewCnd()
+		if fn != nil {
+			fn(cnd)
+		}
+		cnds = append(cnds, cnd)
+	}
+	return cnds
+}
+
+// GroupBy 分组
+func (s *Cnd) GroupBy(columns ...string) *Cnd {
+	for _, column := range columns {
+		if col, ok := s.column(column); ok {
+			s.GroupBys = append(s.GroupBys, col)
+		}
+	}
+	return s
+}
+
+// Having 分组过滤条件，例如：Having("SUM(amount) > ?", 100)
+func (s *Cnd) Having(query string, args ...interface{}) *Cnd {
+	s.Havings = append(s.Havings, ParamPair{query, args})
+	return s
+}
+
 func (s *Cnd) Asc(column string) *Cnd {
-	s.Orders = append(s.Orders, OrderByCol{Column: KeywordWrap(column), Asc: true})
+	if col, ok := s.column(column); ok {
+		s.Orders = append(s.Orders, OrderByCol{Column: col, Asc: true})
+	}
 	return s
 }
 
 func (s *Cnd) Desc(column string) *Cnd {
-	s.Orders = append(s.Orders, OrderByCol{Column: KeywordWrap(column), Asc: false})
+	if col, ok := s.column(column); ok {
+		s.Orders = append(s.Orders, OrderByCol{Column: col, Asc: false})
+	}
+	return s
+}
+
+// OrderBy 添加排序，例如 params.GetSort 的返回值，OrderByCol.Column 不会被校验，不要直接使用请求参数
+func (s *Cnd) OrderBy(orders ...OrderByCol) *Cnd {
+	s.Orders = append(s.Orders, orders...)
+	return s
+}
+
+// Timeout 设置查询超时时间，对 Find、FindOne、Count 及对应的 Ctx 方法生效
+func (s *Cnd) Timeout(timeout time.Duration) *Cnd {
+	s.QueryTimeout = timeout
 	return s
 }
 
@@ -164,32 +518,100 @@ func (s *Cnd) Page(page, limit int) *Cnd {
 }
 
 func (s *Cnd) Build(db *gorm.DB) *gorm.DB {
+	ret := s.buildSelect(db)
+
+	// join
+	ret = s.buildJoins(ret)
+
+	// where
+	ret = s.buildWhere(ret)
+
+	// cursor
+	ret = s.buildCursor(ret)
+
+	// group by、having
+	ret = s.buildGroup(ret)
+
+	// order、limit、offset
+	ret = s.buildOrderAndPaging(ret)
+
+	// 索引提示、行锁
+	return s.buildHints(ret)
+}
+
+// buildCount 构建计数查询，不包含排序和分页
+func (s *Cnd) buildCount(db *gorm.DB) *gorm.DB {
+	return s.buildGroup(s.buildWhere(s.buildJoins(db)))
+}
+
+func (s *Cnd) buildSelect(db *gorm.DB) *gorm.DB {
+	if len(s.SelectCols) == 0 {
+		return db
+	}
+	dialect := DialectOf(db)
+	cols := make([]string, len(s.SelectCols))
+	for i, col := range s.SelectCols {
+		cols[i] = wrapKeyword(dialect, col)
+	}
+	return db.Select(cols)
+}
+
+func (s *Cnd) buildJoins(db *gorm.DB) *gorm.DB {
 	ret := db
+	dialect := DialectOf(db)
+	for _, join := range s.Joins {
+		ret = ret.Joins(join.sql(dialect), join.Args...)
+	}
+	return ret
+}
 
-	if len(s.SelectCols) > 0 {
-		cols := make([]string, len(s.SelectCols))
-		for i, col := range s.SelectCols {
-			cols[i] = KeywordWrap(col)
-		}
-		ret = ret.Select(cols)
+func (s *Cnd) buildGroup(db *gorm.DB) *gorm.DB {
+	ret := db
+	dialect := DialectOf(db)
+	for _, column := range s.GroupBys {
+		ret = ret.Group(wrapKeyword(dialect, column))
+	}
+	for _, having := range s.Havings {
+		ret = ret.Having(having.Query, having.Args...)
 	}
+	return ret
+}
 
-	// where
-	if len(s.Params) > 0 {
-		for _, param := range s.Params {
-			ret = ret.Where(param.Query, param.Args...)
+func (s *Cnd) buildOrderAndPaging(db *gorm.DB) *gorm.DB {
+	ret := db
+
+	// order
+	var (
+		dialect = DialectOf(db)
+		parts   []string
+		vars    []interface{}
+	)
+	for _, order := range s.Orders {
+		query, args, err := order.build(dialect)
+		if err != nil {
+			return withError(ret, err)
+		}
+		if query == "" {
+			continue
+		}
+		parts = append(parts, query+orderDirection(order.Asc))
+		vars = append(vars, args...)
+	}
+	if len(vars) > 0 {
+		// 带参数的排序表达式无法与普通排序字段合并，整体作为一个表达式
+		ret = ret.Order(clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(parts, ", "), Vars: vars, WithoutParentheses: true}})
+	} else {
+		for _, part := range parts {
+			ret = ret.Order(part)
 		}
 	}
 
-	// order
-	if len(s.Orders) > 0 {
-		for _, order := range s.Orders {
-			if order.Asc {
-				ret = ret.Order(order.Column + " ASC")
-			} else {
-				ret = ret.Order(order.Column + " DESC")
-			}
+	// 游标分页，FindCursor 会多查询一条用于判断是否还有数据
+	if s.CursorPaging != nil {
+		if s.CursorPaging.Limit > 0 {
+			ret = ret.Limit(s.CursorPaging.Limit)
 		}
+		return ret
 	}
 
 	// limit
@@ -204,32 +626,119 @@ func (s *Cnd) Build(db *gorm.DB) *gorm.DB {
 	return ret
 }
 
+func (s *Cnd) hasOrderArgs() bool {
+	for _, order := range s.Orders {
+		if len(order.Args) > 0 || order.expr != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// build 生成排序表达式及参数，不包含排序方向，方言不支持时返回空字符串
+func (o OrderByCol) build(d Dialect) (string, []interface{}, error) {
+	if o.expr != nil {
+		return o.expr(d)
+	}
+	if len(o.Args) == 0 && ValidateIdentifier(o.Column) == nil {
+		return wrapKeyword(d, o.Column), nil, nil
+	}
+	return o.Column, o.Args, nil
+}
+
+func orderDirection(asc bool) string {
+	if asc {
+		return " ASC"
+	}
+	return " DESC"
+}
+
+func (s *Cnd) buildWhere(db *gorm.DB) *gorm.DB {
+	ret := db
+	if s.Error != nil {
+		ret = withError(ret, s.Error)
+	}
+	for _, param := range s.Params {
+		args, err := resolveArgs(db, param.Args)
+		if err != nil {
+			return withError(ret, err)
+		}
+		ret = ret.Where(param.Query, args...)
+	}
+	return ret
+}
+
 func (s *Cnd) Find(db *gorm.DB, out interface{}) {
-	if err := s.Build(db).Find(out).Error; err != nil {
+	if err := s.FindCtx(db.Statement.Context, db, out); err != nil {
 		slog.Error(err.Error(), slog.Any("error", err))
 	}
 }
 
 func (s *Cnd) FindOne(db *gorm.DB, out interface{}) error {
-	if err := s.Limit(1).Build(db).First(out).Error; err != nil {
-		return err
-	}
-	return nil
+	return s.FindOneCtx(db.Statement.Context, db, out)
 }
 
 func (s *Cnd) Count(db *gorm.DB, model interface{}) int64 {
-	ret := db.Model(model)
+	count, err := s.CountCtx(db.Statement.Context, db, model)
+	if err != nil {
+		slog.Error(err.Error(), slog.Any("error", err))
+	}
+	return count
+}
 
-	// where
-	if len(s.Params) > 0 {
-		for _, query := range s.Params {
-			ret = ret.Where(query.Query, query.Args...)
-		}
+// FindCtx 查询并返回错误，ctx 取消或超时时中止查询，例如在 iris 中使用 ctx.Request().Context()
+func (s *Cnd) FindCtx(ctx context.Context, db *gorm.DB, out interface{}) error {
+	tx, cancel := s.withContext(ctx, db)
+	defer cancel()
+	return s.Build(tx).Find(out).Error
+}
+
+// FindOneCtx 查询第一条数据，数据不存在时返回 gorm.ErrRecordNotFound
+func (s *Cnd) FindOneCtx(ctx context.Context, db *gorm.DB, out interface{}) error {
+	tx, cancel := s.withContext(ctx, db)
+	defer cancel()
+	return s.Limit(1).Build(tx).First(out).Error
+}
+
+// CountCtx 计数并返回错误，设置了 Distinct 及 Cols 时统计去重后的行数
+func (s *Cnd) CountCtx(ctx context.Context, db *gorm.DB, model interface{}) (int64, error) {
+	tx, cancel := s.withContext(ctx, db)
+	defer cancel()
+
+	query := s.buildCount(tx.Model(model))
+	if s.SelectDistinct && len(s.SelectCols) > 0 {
+		// 多列去重各数据库写法不一，统一使用子查询：SELECT COUNT(*) FROM (SELECT DISTINCT ...) AS t
+		query = tx.Table("(?) AS t", s.buildSelect(query).Distinct())
 	}
 
 	var count int64
-	if err := ret.Count(&count).Error; err != nil {
-		slog.Error(err.Error(), slog.Any("error", err))
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
 	}
-	return count
+	return count, nil
+}
+
+// withContext 为查询设置上下文，设置了 QueryTimeout 时附加超时
+func (s *Cnd) withContext(ctx context.Context, db *gorm.DB) (*gorm.DB, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cancel := context.CancelFunc(func() {})
+	if s.QueryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.QueryTimeout)
+	}
+	return db.WithContext(ctx), cancel
+}
+
+// String 联表语句，表名使用 DB() 对应的方言加引号，Build 时使用传入的 db 对应的方言
+func (j JoinClause) String() string {
+	return j.sql(CurrentDialect())
+}
+
+func (j JoinClause) sql(dialect Dialect) string {
+	table := wrapKeyword(dialect, j.Table)
+	if strs.IsNotBlank(j.Alias) {
+		table += " " + wrapKeyword(dialect, j.Alias)
+	}
+	return j.Type + " JOIN " + table + " ON " + j.On
 }
@@ -0,0 +1,85 @@
+package sqls
+
+import (
+	"gorm.io/gorm"
+)
+
+// AggregateAlias 聚合结果列的别名，分组聚合时对应结构体的 Value 字段或 map 的 "value" 键
+const AggregateAlias = "value"
+
+// Sum 求和，结果为空时返回 0，out 的用法见 Aggregate
+func (s *Cnd) Sum(db *gorm.DB, model interface{}, column string, out interface{}) error {
+	col, ok := s.aggregateColumn(db, column)
+	if !ok {
+		return s.Error
+	}
+	return s.Aggregate(db, model, out, "COALESCE(SUM("+col+"), 0)")
+}
+
+// Avg 求平均值，结果为空时返回 0，out 的用法见 Aggregate
+func (s *Cnd) Avg(db *gorm.DB, model interface{}, column string, out interface{}) error {
+	col, ok := s.aggregateColumn(db, column)
+	if !ok {
+		return s.Error
+	}
+	return s.Aggregate(db, model, out, "COALESCE(AVG("+col+"), 0)")
+}
+
+// Max 求最大值，结果可能为 NULL，未分组时 out 建议使用 sql.NullXxx 或指针类型
+func (s *Cnd) Max(db *gorm.DB, model interface{}, column string, out interface{}) error {
+	col, ok := s.aggregateColumn(db, column)
+	if !ok {
+		return s.Error
+	}
+	return s.Aggregate(db, model, out, "MAX("+col+")")
+}
+
+// Min 求最小值，结果可能为 NULL，未分组时 out 建议使用 sql.NullXxx 或指针类型
+func (s *Cnd) Min(db *gorm.DB, model interface{}, column string, out interface{}) error {
+	col, ok := s.aggregateColumn(db, column)
+	if !ok {
+		return s.Error
+	}
+	return s.Aggregate(db, model, out, "MIN("+col+")")
+}
+
+// CountDistinct 去重计数，out 的用法见 Aggregate
+func (s *Cnd) CountDistinct(db *gorm.DB, model interface{}, column string, out interface{}) error {
+	col, ok := s.aggregateColumn(db, column)
+	if !ok {
+		return s.Error
+	}
+	return s.Aggregate(db, model, out, "COUNT(DISTINCT "+col+")")
+}
+
+// Aggregate 执行聚合查询，expr 为聚合表达式，联表、查询条件、分组、分组过滤与 Build 一致。
+// 未分组时 out 为单个值的指针，例如：*float64、*int64；
+// 分组时查询分组字段及聚合结果（别名为 AggregateAlias），并应用排序和分页，
+// out 可以是结构体切片指针（字段与分组字段、Value 对应），也可以是 *[]map[string]interface{}。
+func (s *Cnd) Aggregate(db *gorm.DB, model interface{}, out interface{}, expr string) error {
+	tx, cancel := s.withContext(db.Statement.Context, db)
+	defer cancel()
+
+	ret := s.buildCount(tx.Model(model))
+	if len(s.GroupBys) == 0 {
+		return ret.Select(expr).Scan(out).Error
+	}
+
+	dialect := DialectOf(db)
+	selects := make([]string, 0, len(s.GroupBys)+1)
+	for _, column := range s.GroupBys {
+		selects = append(selects, wrapKeyword(dialect, column))
+	}
+	selects = append(selects, expr+" AS "+wrapKeyword(dialect, AggregateAlias))
+	ret = s.buildOrderAndPaging(ret.Select(selects))
+	return ret.Find(out).Error
+}
+
+// aggregateColumn 校验聚合的列名并按 db 的方言加引号
+func (s *Cnd) aggregateColumn(db *gorm.DB, column string) (string, bool) {
+	col, ok := s.column(column)
+	if !ok {
+		return "", false
+	}
+	return wrapKeyword(DialectOf(db), col), true
+}
@@ -0,0 +1,119 @@
+package sqls
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+var ErrNoPrimaryKey = errors.New("sqls: model has no single primary key")
+
+// Each 按主键分批遍历符合条件的数据，每批最多 batchSize 条，fn 返回错误时停止遍历并返回该错误。
+// 使用主键 keyset 分页（WHERE pk > ? ORDER BY pk LIMIT ?）而不是 OFFSET，
+// Cnd 的查询条件、查询字段、关联查询生效，排序、分页、分组被忽略。
+// 方法不支持类型参数，因此定义为函数，例如：sqls.Each(cnd, db, 1000, func(batch []User) error {...})
+func Each[T any](cnd *Cnd, db *gorm.DB, batchSize int, fn func(batch []T) error) error {
+	cnd = orNewCnd(cnd)
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return err
+	}
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return ErrNoPrimaryKey
+	}
+	pkColumn := wrapKeyword(DialectOf(db), stmt.Schema.Table+"."+pk.DBName)
+
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var last interface{}
+	for {
+		tx, cancel := cnd.withContext(ctx, db)
+		tx = cnd.buildBatchSelect(tx.Model(new(T)), stmt.Schema.Table, pk.DBName)
+		tx = cnd.buildWhere(cnd.buildJoins(tx))
+		if last != nil {
+			tx = tx.Where(pkColumn+" > ?", last)
+		}
+
+		var batch []T
+		err := tx.Order(pkColumn + " ASC").Limit(batchSize).Find(&batch).Error
+		cancel()
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		value, zero := pk.ValueOf(ctx, reflect.ValueOf(&batch[len(batch)-1]).Elem())
+		if zero {
+			return ErrNoPrimaryKey
+		}
+		last = value
+	}
+}
+
+// All 按主键分批遍历符合条件的数据，逐条返回，查询出错时返回零值与错误并结束遍历，见 Each。
+// 例如：for user, err := range sqls.All[User](cnd, db, 1000) {...}
+func All[T any](cnd *Cnd, db *gorm.DB, batchSize int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		errStop := errors.New("stop")
+		err := Each(cnd, db, batchSize, func(batch []T) error {
+			for _, item := range batch {
+				if !yield(item, nil) {
+					return errStop
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStop) {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// buildBatchSelect 设置查询字段，查询字段中没有主键时补充主键，保证能够取到下一批的起始位置
+func (s *Cnd) buildBatchSelect(db *gorm.DB, table, pk string) *gorm.DB {
+	if len(s.SelectCols) == 0 {
+		return db
+	}
+	dialect := DialectOf(db)
+	cols := make([]string, 0, len(s.SelectCols)+1)
+	hasPk := false
+	for _, col := range s.SelectCols {
+		if col == pk || col == table+"."+pk || col == "*" || col == table+".*" {
+			hasPk = true
+		}
+		cols = append(cols, wrapKeyword(dialect, col))
+	}
+	if !hasPk {
+		cols = append(cols, wrapKeyword(dialect, table+"."+pk))
+	}
+	return db.Select(cols)
+}
+
+// Each 按主键分批遍历符合条件的数据，见 sqls.Each
+func (r *Repo[T]) Each(cnd *Cnd, batchSize int, fn func(batch []T) error) error {
+	return Each(cnd, r.DB(), batchSize, fn)
+}
+
+// All 按主键分批遍历符合条件的数据，逐条返回，见 sqls.All
+func (r *Repo[T]) All(cnd *Cnd, batchSize int) iter.Seq2[T, error] {
+	return All[T](cnd, r.DB(), batchSize)
+}
@@ -0,0 +1,116 @@
+package sqls
+
+import (
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ForUpdate 查询时加排他锁（SELECT ... FOR UPDATE），需要在事务中使用，SQLite、SQL Server 下忽略
+func (s *Cnd) ForUpdate() *Cnd {
+	return s.lock(clause.LockingStrengthUpdate, "")
+}
+
+// ForShare 查询时加共享锁（SELECT ... FOR SHARE，MySQL 8.0 及以上），需要在事务中使用，SQLite、SQL Server 下忽略
+func (s *Cnd) ForShare() *Cnd {
+	return s.lock(clause.LockingStrengthShare, "")
+}
+
+// SkipLocked 跳过已被锁定的行，未调用 ForUpdate、ForShare 时使用 FOR UPDATE，
+// 例如领取待处理任务：NewCnd().Eq("status", "pending").Asc("id").Limit(10).ForUpdate().SkipLocked()
+func (s *Cnd) SkipLocked() *Cnd {
+	return s.lock("", clause.LockingOptionsSkipLocked)
+}
+
+// NoWait 行已被锁定时立即返回错误而不是等待，未调用 ForUpdate、ForShare 时使用 FOR UPDATE
+func (s *Cnd) NoWait() *Cnd {
+	return s.lock("", clause.LockingOptionsNoWait)
+}
+
+func (s *Cnd) lock(strength, options string) *Cnd {
+	if s.Lock == nil {
+		s.Lock = &clause.Locking{Strength: clause.LockingStrengthUpdate}
+	}
+	if strength != "" {
+		s.Lock.Strength = strength
+	}
+	if options != "" {
+		s.Lock.Options = options
+	}
+	return s
+}
+
+// Distinct 查询结果去重（SELECT DISTINCT），通常与 Cols 一起使用
+func (s *Cnd) Distinct() *Cnd {
+	s.SelectDistinct = true
+	return s
+}
+
+// UseIndex 提示使用指定的索引，目前只有 MySQL 支持，其他数据库下忽略
+func (s *Cnd) UseIndex(indexes ...string) *Cnd {
+	return s.indexHint(UseIndex, indexes)
+}
+
+// ForceIndex 强制使用指定的索引，见 UseIndex
+func (s *Cnd) ForceIndex(indexes ...string) *Cnd {
+	return s.indexHint(ForceIndex, indexes)
+}
+
+// IgnoreIndex 忽略指定的索引，见 UseIndex
+func (s *Cnd) IgnoreIndex(indexes ...string) *Cnd {
+	return s.indexHint(IgnoreIndex, indexes)
+}
+
+func (s *Cnd) indexHint(hintType IndexHintType, indexes []string) *Cnd {
+	if len(indexes) == 0 {
+		return s
+	}
+	for _, index := range indexes {
+		if !isIdentifierPart(index) {
+			return s.AddError(fmt.Errorf("%w: index %q", ErrInvalidColumn, index))
+		}
+	}
+	s.IndexHintList = append(s.IndexHintList, IndexHint{Type: hintType, Indexes: indexes})
+	return s
+}
+
+// buildHints 设置去重、索引提示及行锁，数据库不支持时记录警告日志并忽略
+func (s *Cnd) buildHints(db *gorm.DB) *gorm.DB {
+	ret := db
+	if s.SelectDistinct {
+		ret = ret.Distinct()
+	}
+
+	dialect := DialectOf(db)
+	if len(s.IndexHintList) > 0 {
+		if hints, ok := dialect.IndexHints(s.IndexHintList); ok {
+			ret = ret.Table("?", indexHintTable{hints: hints})
+		} else {
+			slog.Warn("sqls: index hints are not supported, ignored", slog.String("dialect", dialect.Name()))
+		}
+	}
+
+	if s.Lock != nil {
+		if locking, ok := dialect.Locking(s.Lock.Strength, s.Lock.Options); ok {
+			// 加锁的查询只能在主库执行
+			ret = ForcePrimary(ret.Clauses(locking))
+		} else {
+			slog.Warn("sqls: row locking is not supported, ignored", slog.String("dialect", dialect.Name()))
+		}
+	}
+	return ret
+}
+
+// indexHintTable 带索引提示的表名，生成 SQL 时表名已从模型或查询结果解析得到
+type indexHintTable struct {
+	hints string
+}
+
+func (t indexHintTable) Build(builder clause.Builder) {
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		builder.WriteQuoted(clause.Table{Name: stmt.Table})
+	}
+	builder.WriteString(" " + t.hints)
+}
@@ -0,0 +1,76 @@
+package sqls
+
+import (
+	"errors"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// SQLStatement SQL 语句及其参数
+type SQLStatement struct {
+	SQL  string        // SQL 语句
+	Vars []interface{} // 参数，内联参数时为空
+}
+
+// CndSQL Build、Count 将要执行的 SQL
+type CndSQL struct {
+	Select SQLStatement // Build 后 Find 执行的查询语句
+	Count  SQLStatement // Count 执行的计数语句
+}
+
+type toSQLOptions struct {
+	inline bool
+}
+
+// ToSQLOption ToSQL 选项
+type ToSQLOption func(o *toSQLOptions)
+
+// InlineVars 将参数按方言格式内联到 SQL 中，便于日志输出，生成的 SQL 仅用于调试，不要直接执行
+func InlineVars() ToSQLOption {
+	return func(o *toSQLOptions) {
+		o.inline = true
+	}
+}
+
+// ToSQL 使用 GORM DryRun 生成 Build、Count 将要执行的 SQL 及参数，不会真正执行。
+// db 需要通过 Model 或 Table 指定要查询的表，例如：cnd.ToSQL(sqls.DB().Model(&User{}))
+func (s *Cnd) ToSQL(db *gorm.DB, opts ...ToSQLOption) (*CndSQL, error) {
+	options := &toSQLOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	model := db.Statement.Model
+	if model == nil && db.Statement.Table == "" {
+		return nil, errors.New("sqls: ToSQL requires db with Model or Table")
+	}
+	var dest interface{} = &[]map[string]interface{}{}
+	if model != nil {
+		dest = reflect.New(reflect.SliceOf(reflect.Indirect(reflect.ValueOf(model)).Type())).Interface()
+	}
+
+	dry := db.Session(&gorm.Session{DryRun: true})
+	selectStmt := s.Build(dry).Find(dest)
+	if selectStmt.Error != nil {
+		return nil, selectStmt.Error
+	}
+	var count int64
+	countStmt := s.buildCount(dry).Count(&count)
+	if countStmt.Error != nil {
+		return nil, countStmt.Error
+	}
+
+	return &CndSQL{
+		Select: toSQLStatement(selectStmt, options),
+		Count:  toSQLStatement(countStmt, options),
+	}, nil
+}
+
+func toSQLStatement(db *gorm.DB, options *toSQLOptions) SQLStatement {
+	sql, vars := db.Statement.SQL.String(), db.Statement.Vars
+	if options.inline {
+		return SQLStatement{SQL: db.Dialector.Explain(sql, vars...)}
+	}
+	return SQLStatement{SQL: sql, Vars: vars}
+}
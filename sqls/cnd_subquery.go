@@ -0,0 +1,114 @@
+package sqls
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrSubqueryNoColumn = errors.New("sqls: subquery of IN requires exactly one select column")
+
+// subquery 子查询参数，执行查询时才根据外层的 db 构建为 *gorm.DB，
+// GORM 会将其展开为 SQL 并按出现的位置合并参数
+type subquery struct {
+	cnd    *Cnd
+	model  interface{} // 模型或表名
+	exists bool
+}
+
+// InSubquery column 在子查询的结果中，子查询需要通过 Cols 指定唯一的查询字段，model 为子查询的模型或表名，例如：
+// NewCnd().InSubquery("id", NewCnd().Cols("user_id").Eq("status", "paid"), &Order{})
+func (s *Cnd) InSubquery(column string, sub *Cnd, model interface{}) *Cnd {
+	return s.inSubquery(column, "IN", sub, model)
+}
+
+// NotInSubquery column 不在子查询的结果中，见 InSubquery
+func (s *Cnd) NotInSubquery(column string, sub *Cnd, model interface{}) *Cnd {
+	return s.inSubquery(column, "NOT IN", sub, model)
+}
+
+// Exists 子查询存在数据，可以通过 EqCol 引用外层的表实现关联子查询，例如：
+// NewCnd().Exists(NewCnd().EqCol("orders.user_id", "users.id").Eq("orders.status", "paid"), &Order{})
+func (s *Cnd) Exists(sub *Cnd, model interface{}) *Cnd {
+	return s.exists("EXISTS", sub, model)
+}
+
+// NotExists 子查询不存在数据，见 Exists
+func (s *Cnd) NotExists(sub *Cnd, model interface{}) *Cnd {
+	return s.exists("NOT EXISTS", sub, model)
+}
+
+// EqCol 两列相等，用于关联子查询中引用外层的表或联表条件，例如：EqCol("orders.user_id", "users.id")
+func (s *Cnd) EqCol(column, otherColumn string) *Cnd {
+	col, ok := s.column(column)
+	if !ok {
+		return s
+	}
+	if other, ok := s.column(otherColumn); ok {
+		s.Where("? = ?", columnExpr(col), columnExpr(other))
+	}
+	return s
+}
+
+func (s *Cnd) inSubquery(column, op string, sub *Cnd, model interface{}) *Cnd {
+	if sub == nil || len(sub.SelectCols) != 1 {
+		return s.AddError(ErrSubqueryNoColumn)
+	}
+	if sub.Error != nil {
+		return s.AddError(sub.Error)
+	}
+	if col, ok := s.column(column); ok {
+		s.Where("? "+op+" (?)", columnExpr(col), &subquery{cnd: sub, model: model})
+	}
+	return s
+}
+
+func (s *Cnd) exists(op string, sub *Cnd, model interface{}) *Cnd {
+	sub = orNewCnd(sub)
+	if sub.Error != nil {
+		return s.AddError(sub.Error)
+	}
+	return s.Where(op+" (?)", &subquery{cnd: sub, model: model, exists: true})
+}
+
+// resolveArgs 将参数中的子查询构建为基于 db 的 *gorm.DB，依赖方言的 SQL 片段按 db 的方言生成
+func resolveArgs(db *gorm.DB, args []interface{}) ([]interface{}, error) {
+	var resolved []interface{}
+	for i, arg := range args {
+		var value interface{}
+		switch v := arg.(type) {
+		case *subquery:
+			value = v.build(db)
+		case dialectExpr:
+			query, vars, err := v(DialectOf(db))
+			if err != nil {
+				return nil, err
+			}
+			value = clause.Expr{SQL: query, Vars: vars}
+		default:
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]interface{}{}, args...)
+		}
+		resolved[i] = value
+	}
+	if resolved == nil {
+		return args, nil
+	}
+	return resolved, nil
+}
+
+func (q *subquery) build(db *gorm.DB) *gorm.DB {
+	tx := db.Session(&gorm.Session{NewDB: true})
+	if table, ok := q.model.(string); ok {
+		tx = tx.Table(table)
+	} else {
+		tx = tx.Model(q.model)
+	}
+	if q.exists && len(q.cnd.SelectCols) == 0 {
+		tx = tx.Select("1")
+	}
+	return q.cnd.Build(tx)
+}
@@ -0,0 +1,522 @@
+package sqls_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// 测试用的模型
+type CndUser struct {
+	ID     int64 `gorm:"primarykey"`
+	Name   string
+	Phone  string
+	Age    int
+	Status int
+}
+
+type CndOrder struct {
+	ID     int64 `gorm:"primarykey"`
+	UserId int64
+	Amount float64
+	Status string
+	Day    string
+}
+
+// 设置测试数据库并写入测试数据
+func setupCndTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&CndUser{}, &CndOrder{}))
+
+	users := []CndUser{
+		{Name: "tom", Phone: "13800000001", Age: 18, Status: 0},
+		{Name: "jerry", Phone: "13800000002", Age: 20, Status: 1},
+		{Name: "alice", Phone: "13900000003", Age: 25, Status: 0},
+		{Name: "bob", Phone: "13900000004", Age: 30, Status: 1},
+	}
+	assert.NoError(t, db.Create(&users).Error)
+
+	orders := []CndOrder{
+		{UserId: 1, Amount: 10, Status: "paid", Day: "2024-01-01"},
+		{UserId: 1, Amount: 20, Status: "paid", Day: "2024-01-02"},
+		{UserId: 2, Amount: 30, Status: "unpaid", Day: "2024-01-01"},
+		{UserId: 3, Amount: 40, Status: "paid", Day: "2024-01-02"},
+	}
+	assert.NoError(t, db.Create(&orders).Error)
+
+	sqls.SetDB(db)
+	return db
+}
+
+// 测试 OR 条件组与其他条件 AND 组合
+func TestCnd_Or(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	cnd := sqls.NewCnd().
+		Eq("status", 0).
+		Or(sqls.NewCnd().Like("name", "tom"), sqls.NewCnd().Like("phone", "0004"))
+
+	var list []CndUser
+	cnd.Find(db, &list)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "tom", list[0].Name)
+	assert.Equal(t, int64(1), cnd.Count(db, &CndUser{}))
+}
+
+// 测试闭包形式的嵌套条件组
+func TestCnd_NestedFunc(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	// age > 24 AND (status = 1 OR NOT (name = 'alice'))
+	cnd := sqls.NewCnd().
+		Gt("age", 24).
+		OrFunc(
+			func(sub *sqls.Cnd) { sub.Eq("status", 1) },
+			func(sub *sqls.Cnd) { sub.NotFunc(func(not *sqls.Cnd) { not.Eq("name", "alice") }) },
+		)
+
+	var list []CndUser
+	cnd.Find(db, &list)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "bob", list[0].Name)
+}
+
+// 测试空条件组不会产生条件
+func TestCnd_EmptyGroup(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	cnd := sqls.NewCnd().Or(sqls.NewCnd(), nil).And().Not(nil).Not(sqls.NewCnd())
+	assert.Empty(t, cnd.Params)
+	assert.Equal(t, int64(4), cnd.Count(db, &CndUser{}))
+}
+
+// 测试联表查询及联表计数
+func TestCnd_Join(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	cnd := sqls.NewCnd().
+		InnerJoin("cnd_users", "u", "u.id = cnd_orders.user_id").
+		Eq("u.status", 0).
+		Eq("cnd_orders.status", "paid").
+		Desc("cnd_orders.amount")
+
+	var list []CndOrder
+	cnd.Find(db, &list)
+	assert.Len(t, list, 3)
+	assert.Equal(t, float64(40), list[0].Amount)
+	assert.Equal(t, int64(3), cnd.Count(db, &CndOrder{}))
+
+	// 左联表，查询联表字段
+	type row struct {
+		Amount   float64
+		UserName string
+	}
+	var rows []row
+	sqls.NewCnd().
+		Cols("cnd_orders.amount", "u.name AS user_name").
+		LeftJoin("cnd_users", "u", "u.id = cnd_orders.user_id AND u.age > ?", 18).
+		Asc("cnd_orders.id").
+		Find(db.Model(&CndOrder{}), &rows)
+	assert.Len(t, rows, 4)
+	assert.Equal(t, "", rows[0].UserName)
+	assert.Equal(t, "jerry", rows[2].UserName)
+}
+
+func TestKeywordWrap(t *testing.T) {
+	setupCndTestDB(t)
+
+	assert.Equal(t, "`name`", sqls.KeywordWrap("name"))
+	assert.Equal(t, "`u`.`name`", sqls.KeywordWrap("u.name"))
+	assert.Equal(t, "`u`.*", sqls.KeywordWrap("u.*"))
+	assert.Equal(t, "`u`.`name` AS `user_name`", sqls.KeywordWrap("u.name as user_name"))
+	assert.Equal(t, "*", sqls.KeywordWrap("*"))
+}
+
+// 测试聚合查询
+func TestCnd_Aggregate(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	var sum float64
+	assert.NoError(t, sqls.NewCnd().Eq("status", "paid").Sum(db, &CndOrder{}, "amount", &sum))
+	assert.Equal(t, float64(70), sum)
+
+	var empty float64
+	assert.NoError(t, sqls.NewCnd().Eq("status", "none").Sum(db, &CndOrder{}, "amount", &empty))
+	assert.Equal(t, float64(0), empty)
+
+	var users int64
+	assert.NoError(t, sqls.NewCnd().CountDistinct(db, &CndOrder{}, "user_id", &users))
+	assert.Equal(t, int64(3), users)
+
+	// 按状态分组，扫描到结构体
+	type stat struct {
+		Status string
+		Value  float64
+	}
+	var stats []stat
+	cnd := sqls.NewCnd().GroupBy("status").Asc("status")
+	assert.NoError(t, cnd.Sum(db, &CndOrder{}, "amount", &stats))
+	assert.Equal(t, []stat{{"paid", 70}, {"unpaid", 30}}, stats)
+	assert.Equal(t, int64(2), cnd.Count(db, &CndOrder{}))
+
+	// 按天分组并过滤，扫描到 map
+	var rows []map[string]interface{}
+	assert.NoError(t, sqls.NewCnd().
+		GroupBy("day").
+		Having("MAX(amount) > ?", 30).
+		Max(db, &CndOrder{}, "amount", &rows))
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "2024-01-02", rows[0]["day"])
+	assert.EqualValues(t, 40, rows[0]["value"])
+}
+
+// 测试游标分页：多字段排序、翻页直到没有更多数据
+func TestCnd_FindCursor(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	var (
+		names  []string
+		cursor string
+		pages  int
+	)
+	for {
+		var list []CndUser
+		next, hasMore, err := sqls.NewCnd().
+			Desc("status").
+			Asc("id").
+			Cursor(cursor, 3).
+			FindCursor(db, &list)
+		assert.NoError(t, err)
+		for _, user := range list {
+			names = append(names, user.Name)
+		}
+		pages++
+		cursor = next
+		if !hasMore {
+			break
+		}
+	}
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{"jerry", "bob", "tom", "alice"}, names)
+
+	// Find 等方法只查询 limit 条
+	var first []CndUser
+	assert.NoError(t, sqls.NewCnd().Asc("id").Cursor("", 2).FindCtx(t.Context(), db, &first))
+	assert.Len(t, first, 2)
+
+	// 篡改游标
+	var list []CndUser
+	_, _, err := sqls.NewCnd().Desc("status").Asc("id").Cursor(cursor+"x", 3).FindCursor(db, &list)
+	assert.ErrorIs(t, err, sqls.ErrInvalidCursor)
+
+	// 排序不一致的游标
+	_, _, err = sqls.NewCnd().Asc("id").Cursor(cursor, 3).FindCursor(db, &list)
+	assert.ErrorIs(t, err, sqls.ErrInvalidCursor)
+}
+
+// 测试生成 SQL
+func TestCnd_ToSQL(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	cnd := sqls.NewCnd().
+		Eq("status", 1).
+		Or(sqls.NewCnd().Like("name", "o"), sqls.NewCnd().Gt("age", 25)).
+		Desc("id").
+		Page(2, 10)
+
+	ret, err := cnd.ToSQL(db.Model(&CndUser{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `cnd_users` WHERE `status` = (?) AND (((`name` LIKE ? ESCAPE '!') OR (`age` > (?)))) ORDER BY `id` DESC LIMIT 10 OFFSET 10", ret.Select.SQL)
+	assert.Equal(t, []interface{}{1, "%o%", 25}, ret.Select.Vars)
+	assert.Equal(t, "SELECT count(*) FROM `cnd_users` WHERE `status` = (?) AND (((`name` LIKE ? ESCAPE '!') OR (`age` > (?))))", ret.Count.SQL)
+
+	ret, err = cnd.ToSQL(db.Table("cnd_users"), sqls.InlineVars())
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT count(*) FROM `cnd_users` WHERE `status` = (1) AND (((`name` LIKE \"%o%\" ESCAPE '!') OR (`age` > (25))))", ret.Count.SQL)
+	assert.Empty(t, ret.Count.Vars)
+
+	_, err = cnd.ToSQL(db)
+	assert.Error(t, err)
+
+	// ToSQL 不会执行查询
+	var count int64
+	db.Model(&CndUser{}).Count(&count)
+	assert.Equal(t, int64(4), count)
+}
+
+// 测试带上下文的查询：取消、超时时返回错误
+func TestCnd_Context(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	var list []CndUser
+	assert.NoError(t, sqls.NewCnd().Eq("status", 1).FindCtx(context.Background(), db, &list))
+	assert.Len(t, list, 2)
+
+	count, err := sqls.NewCnd().Eq("status", 1).CountCtx(context.Background(), db, &CndUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	var user CndUser
+	err = sqls.NewCnd().Eq("name", "nobody").FindOneCtx(context.Background(), db, &user)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = sqls.NewCnd().FindCtx(ctx, db, &list)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = sqls.NewCnd().CountCtx(ctx, db, &CndUser{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = sqls.NewCnd().Timeout(time.Nanosecond).FindCtx(context.Background(), db, &list)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 超时对游标分页、聚合及 Repo 同样生效
+	timeout := func() *sqls.Cnd { return sqls.NewCnd().Asc("id").Timeout(time.Nanosecond) }
+	_, _, err = timeout().Cursor("", 2).FindCursor(db, &list)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var sum float64
+	assert.ErrorIs(t, timeout().Sum(db, &CndUser{}, "age", &sum), context.DeadlineExceeded)
+	repo := sqls.NewRepo[CndUser]().With(db)
+	_, err = repo.Find(timeout())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = repo.Take(timeout())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = repo.Count(timeout())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = repo.Exists(timeout())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// 测试非法列名：记录错误且不会拼接到 SQL 中
+func TestCnd_InvalidColumn(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	cnd := sqls.NewCnd().Eq("name` = 'tom' OR `1", 1)
+	assert.ErrorIs(t, cnd.Error, sqls.ErrInvalidColumn)
+	assert.Empty(t, cnd.Params)
+
+	var list []CndUser
+	assert.ErrorIs(t, cnd.FindCtx(context.Background(), db, &list), sqls.ErrInvalidColumn)
+	_, err := cnd.CountCtx(context.Background(), db, &CndUser{})
+	assert.ErrorIs(t, err, sqls.ErrInvalidColumn)
+
+	// 错误不会影响传入的 db
+	assert.NoError(t, sqls.NewCnd().FindCtx(context.Background(), db, &list))
+	assert.Len(t, list, 4)
+
+	// 子条件中的错误传递给外层，非法条件被忽略时不会放宽更新的范围
+	cnd = sqls.NewCnd().Eq("status", 1).Or(sqls.NewCnd().Eq("name`; --", "a"))
+	assert.ErrorIs(t, cnd.Error, sqls.ErrInvalidColumn)
+	_, err = cnd.Update(db, &CndUser{}, map[string]interface{}{"age": 99})
+	assert.ErrorIs(t, err, sqls.ErrInvalidColumn)
+	assert.Equal(t, int64(0), sqls.NewCnd().Eq("age", 99).Count(db, &CndUser{}))
+	assert.ErrorIs(t, sqls.NewCnd().And(sqls.NewCnd().Gt("age;", 1)).Error, sqls.ErrInvalidColumn)
+	assert.ErrorIs(t, sqls.NewCnd().NotFunc(func(sub *sqls.Cnd) { sub.Eq("a b", 1) }).Error, sqls.ErrInvalidColumn)
+
+	// KeywordWrap 转义引号
+	assert.Equal(t, "`a``b`", sqls.KeywordWrap("a`b"))
+	assert.Equal(t, "```a`` OR 1 = 1 -- ```", sqls.KeywordWrap("`a` OR 1 = 1 -- `"))
+	assert.Equal(t, "`a``b`", sqls.KeywordWrap("`a``b`"))
+}
+
+// 测试允许使用的列
+func TestCnd_AllowModel(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	cnd := sqls.NewCnd().AllowModel(db, &CndUser{}).Eq("name", "tom").Eq("u.status", 0).Desc("age")
+	assert.NoError(t, cnd.Error)
+
+	cnd.Eq("password", "x")
+	assert.ErrorIs(t, cnd.Error, sqls.ErrColumnNotAllowed)
+	var list []CndUser
+	assert.ErrorIs(t, cnd.FindCtx(context.Background(), db, &list), sqls.ErrColumnNotAllowed)
+
+	columns, err := sqls.ModelColumns(db, &CndUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "phone", "age", "status"}, columns)
+}
+
+// 测试按主键分批遍历
+func TestEach(t *testing.T) {
+	db := setupCndTestDB(t)
+	more := make([]CndUser, 0, 10)
+	for i := 0; i < 10; i++ {
+		more = append(more, CndUser{Name: "batch", Age: 40 + i})
+	}
+	assert.NoError(t, db.Create(&more).Error)
+
+	var sizes []int
+	var ages []int
+	err := sqls.Each(sqls.NewCnd().Cols("age").Gte("age", 20).Desc("age").Limit(1), db, 4, func(batch []CndUser) error {
+		sizes = append(sizes, len(batch))
+		for _, user := range batch {
+			assert.NotZero(t, user.ID, "查询字段中应该补充主键")
+			assert.Empty(t, user.Name)
+			ages = append(ages, user.Age)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 4, 4, 1}, sizes, "排序与分页被忽略")
+	assert.Equal(t, []int{20, 25, 30, 40}, ages[:4])
+
+	// 迭代器提前结束
+	var names []string
+	for user, err := range sqls.NewRepo[CndUser]().All(sqls.NewCnd().Eq("name", "batch"), 3) {
+		assert.NoError(t, err)
+		names = append(names, user.Name)
+		if len(names) == 5 {
+			break
+		}
+	}
+	assert.Len(t, names, 5)
+
+	errCount := 0
+	for _, err := range sqls.All[CndUser](sqls.NewCnd().Eq("name; --", "x"), db, 3) {
+		assert.ErrorIs(t, err, sqls.ErrInvalidColumn)
+		errCount++
+	}
+	assert.Equal(t, 1, errCount)
+}
+
+// 测试基于条件的更新与删除，没有条件时拒绝执行
+func TestCnd_UpdateDelete(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	n, err := sqls.NewCnd().Eq("status", 1).Update(db, &CndUser{}, map[string]interface{}{"status": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = sqls.NewCnd().Gte("age", 25).UpdateColumn(db, &CndUser{}, "phone", "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	_, err = sqls.NewCnd().Eq("status", 2).UpdateColumn(db, &CndUser{}, "phone = 1, age", 0)
+	assert.ErrorIs(t, err, sqls.ErrInvalidColumn)
+
+	// 没有条件（例如请求中没有任何过滤参数）时拒绝更新、删除全表
+	_, err = sqls.NewCnd().Update(db, &CndUser{}, map[string]interface{}{"status": 0})
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+	_, err = sqls.NewCnd().Delete(db, &CndUser{})
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+	_, err = sqls.NewCnd().Eq("bad col", 1).Delete(db, &CndUser{})
+	assert.ErrorIs(t, err, sqls.ErrInvalidColumn)
+
+	n, err = sqls.NewCnd().Eq("status", 2).Delete(db, &CndUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = sqls.NewCnd().AllowGlobal().Update(db, &CndUser{}, map[string]interface{}{"status": 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = sqls.NewCnd().AllowGlobal().Delete(db, &CndUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+// 测试子查询及 EXISTS 条件
+func TestCnd_Subquery(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	var names []string
+	findNames := func(cnd *sqls.Cnd) []string {
+		var users []CndUser
+		assert.NoError(t, cnd.Asc("id").FindCtx(t.Context(), db, &users))
+		names = names[:0]
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		return names
+	}
+
+	paid := sqls.NewCnd().Cols("user_id").Eq("status", "paid").Gt("amount", 15)
+	assert.Equal(t, []string{"tom", "alice"}, findNames(sqls.NewCnd().Gte("age", 18).InSubquery("id", paid, &CndOrder{})))
+	assert.Equal(t, []string{"jerry", "bob"}, findNames(sqls.NewCnd().NotInSubquery("id", paid, "cnd_orders")))
+
+	// 关联子查询，参数按出现的顺序合并
+	hasPaid := sqls.NewCnd().EqCol("cnd_orders.user_id", "cnd_users.id").Eq("cnd_orders.status", "paid")
+	cnd := sqls.NewCnd().Eq("status", 0).Exists(hasPaid, &CndOrder{}).Lt("age", 30)
+	assert.Equal(t, []string{"tom", "alice"}, findNames(cnd))
+	assert.Equal(t, []string{"bob"}, findNames(sqls.NewCnd().NotExists(hasPaid, &CndOrder{}).Gt("age", 20)))
+
+	ret, err := cnd.ToSQL(db.Model(&CndUser{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `cnd_users` WHERE `status` = (?) AND EXISTS (SELECT 1 FROM `cnd_orders` WHERE `cnd_orders`.`user_id` = `cnd_users`.`id` AND `cnd_orders`.`status` = (?)) AND `age` < (?) ORDER BY `id` ASC", ret.Select.SQL)
+	assert.Equal(t, []interface{}{0, "paid", 30}, ret.Select.Vars)
+
+	// 子查询在 OR 条件组中
+	assert.Equal(t, []string{"tom", "jerry", "alice"}, findNames(sqls.NewCnd().Or(
+		sqls.NewCnd().Exists(hasPaid, &CndOrder{}),
+		sqls.NewCnd().Eq("name", "jerry"),
+	)))
+
+	assert.ErrorIs(t, sqls.NewCnd().InSubquery("id", sqls.NewCnd(), &CndOrder{}).Error, sqls.ErrSubqueryNoColumn)
+}
+
+// 测试 LIKE 参数中的通配符按字面匹配及大小写不敏感的匹配
+func TestCnd_LikeEscape(t *testing.T) {
+	db := setupCndTestDB(t)
+	assert.NoError(t, db.Create(&[]CndUser{{Name: "100%_off"}, {Name: "Tom!"}}).Error)
+
+	count := func(cnd *sqls.Cnd) int64 {
+		return cnd.Count(db, &CndUser{})
+	}
+	assert.Equal(t, int64(1), count(sqls.NewCnd().Like("name", "100%")))
+	assert.Equal(t, int64(1), count(sqls.NewCnd().Like("name", "_")))
+	assert.Equal(t, int64(0), count(sqls.NewCnd().Starting("name", "%")))
+	assert.Equal(t, int64(1), count(sqls.NewCnd().Ending("name", "%_off")))
+	assert.Equal(t, int64(1), count(sqls.NewCnd().Ending("name", "!")))
+
+	// SQLite 的 LIKE 对 ASCII 字符本身大小写不敏感，这里只验证 ILike 系列生成的条件可以正确执行
+	assert.Equal(t, int64(2), count(sqls.NewCnd().IStarting("name", "TO")))
+	assert.Equal(t, int64(1), count(sqls.NewCnd().ILike("name", "M!")))
+	assert.Equal(t, int64(1), count(sqls.NewCnd().IEnding("name", "ICE")))
+	assert.Equal(t, "a!%b!_c!!", sqls.EscapeLike("a%b_c!"))
+}
+
+// 测试行锁、去重及索引提示
+func TestCnd_LockAndHints(t *testing.T) {
+	db := setupCndTestDB(t)
+
+	// SQLite 不支持行锁和索引提示，忽略后正常查询
+	var list []CndUser
+	assert.NoError(t, sqls.NewCnd().Eq("status", 1).ForUpdate().SkipLocked().UseIndex("idx_status").FindCtx(t.Context(), db, &list))
+	assert.Len(t, list, 2)
+
+	var statuses []int
+	assert.NoError(t, sqls.NewCnd().Cols("status").Distinct().Asc("status").FindCtx(t.Context(), db.Model(&CndUser{}), &statuses))
+	assert.Equal(t, []int{0, 1}, statuses)
+
+	// 去重查询的计数与查询结果行数一致
+	count, err := sqls.NewCnd().Cols("status").Distinct().CountCtx(t.Context(), db, &CndUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	count, err = sqls.NewCnd().Cols("status", "id").Distinct().Gt("id", 1).CountCtx(t.Context(), db, &CndUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	count, err = sqls.NewRepo[CndUser]().Count(sqls.NewCnd().Cols("status").Distinct())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	mysqlDB, err := gorm.Open(mysql.New(mysql.Config{DSN: "root@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.NoError(t, err)
+
+	cnd := sqls.NewCnd().Eq("status", 0).Asc("id").Limit(10).ForUpdate().SkipLocked().UseIndex("idx_status").IgnoreIndex("idx_name", "idx_phone")
+	ret, err := cnd.ToSQL(mysqlDB.Model(&CndUser{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `cnd_users` USE INDEX (`idx_status`) IGNORE INDEX (`idx_name`, `idx_phone`) WHERE `status` = (?) ORDER BY `id` ASC LIMIT ? FOR UPDATE SKIP LOCKED", ret.Select.SQL)
+
+	ret, err = sqls.NewCnd().Cols("status").Distinct().ForShare().NoWait().ToSQL(mysqlDB.Model(&CndUser{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT DISTINCT `status` FROM `cnd_users` FOR SHARE NOWAIT", ret.Select.SQL)
+
+	assert.ErrorIs(t, sqls.NewCnd().UseIndex("idx) FORCE INDEX (x").Error, sqls.ErrInvalidColumn)
+}
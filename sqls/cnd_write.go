@@ -0,0 +1,53 @@
+package sqls
+
+import (
+	"gorm.io/gorm"
+)
+
+// AllowGlobal 允许在没有查询条件时执行 Update、UpdateColumn、Delete，即更新、删除全表数据
+func (s *Cnd) AllowGlobal() *Cnd {
+	s.Global = true
+	return s
+}
+
+// Update 更新符合条件的数据，values 可以是 map 或结构体（结构体零值字段不会更新），返回影响的行数。
+// 没有查询条件且未调用 AllowGlobal 时返回 gorm.ErrMissingWhereClause；联表、分组、排序、分页被忽略
+func (s *Cnd) Update(db *gorm.DB, model interface{}, values interface{}) (int64, error) {
+	return s.write(db, model, func(tx *gorm.DB) *gorm.DB {
+		return tx.Updates(values)
+	})
+}
+
+// UpdateColumn 更新符合条件的数据的单个字段，不触发钩子、不更新 UpdatedAt，返回影响的行数，见 Update
+func (s *Cnd) UpdateColumn(db *gorm.DB, model interface{}, column string, value interface{}) (int64, error) {
+	if err := s.checkColumn(column); err != nil {
+		return 0, err
+	}
+	return s.write(db, model, func(tx *gorm.DB) *gorm.DB {
+		return tx.UpdateColumn(column, value)
+	})
+}
+
+// Delete 删除符合条件的数据，模型包含 gorm.DeletedAt 时为软删除，返回影响的行数，见 Update
+func (s *Cnd) Delete(db *gorm.DB, model interface{}) (int64, error) {
+	return s.write(db, model, func(tx *gorm.DB) *gorm.DB {
+		return tx.Delete(model)
+	})
+}
+
+func (s *Cnd) write(db *gorm.DB, model interface{}, exec func(tx *gorm.DB) *gorm.DB) (int64, error) {
+	if s.Error != nil {
+		return 0, s.Error
+	}
+	if len(s.Params) == 0 && !s.Global {
+		return 0, gorm.ErrMissingWhereClause
+	}
+
+	tx, cancel := s.withContext(db.Statement.Context, db)
+	defer cancel()
+	if s.Global {
+		tx = tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+	}
+	ret := exec(s.buildWhere(tx.Model(model)))
+	return ret.RowsAffected, ret.Error
+}
@@ -0,0 +1,254 @@
+package sqls
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCursor  = errors.New("sqls: invalid cursor")
+	ErrCursorNoOrders = errors.New("sqls: cursor paging requires at least one order column")
+)
+
+// cursorSecret 游标签名密钥，默认为进程启动时随机生成，多实例部署时需通过 SetCursorSecret 设置一致的密钥
+var cursorSecret = func() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+}()
+
+// SetCursorSecret 设置游标签名密钥
+func SetCursorSecret(secret []byte) {
+	cursorSecret = secret
+}
+
+// CursorPaging 游标分页请求数据
+type CursorPaging struct {
+	Cursor string `json:"cursor"` // 上一页返回的游标，为空表示第一页
+	Limit  int    `json:"limit"`  // 每页条数
+}
+
+// Cursor 使用游标（keyset）分页，按当前 Orders 依次比较，最后一个排序字段应当唯一（例如：id）以保证结果稳定
+func (s *Cnd) Cursor(cursor string, limit int) *Cnd {
+	s.CursorPaging = &CursorPaging{Cursor: cursor, Limit: limit}
+	return s
+}
+
+// FindCursor 游标分页查询，多查询一条用于判断是否还有数据，返回最后一条数据的游标，
+// 结果可直接用于 web.JsonCursorData(out, cursor, hasMore)
+func (s *Cnd) FindCursor(db *gorm.DB, out interface{}) (cursor string, hasMore bool, err error) {
+	if s.CursorPaging == nil || s.CursorPaging.Limit <= 0 {
+		return "", false, errors.New("sqls: cursor paging is not set")
+	}
+	if len(s.Orders) == 0 {
+		return "", false, ErrCursorNoOrders
+	}
+	if s.hasOrderArgs() {
+		return "", false, errors.New("sqls: cursor paging does not support order expressions with args")
+	}
+	tx, cancel := s.withContext(db.Statement.Context, db)
+	defer cancel()
+	// 多查询一条用于判断是否还有数据
+	if err = s.Build(tx).Limit(s.CursorPaging.Limit + 1).Find(out).Error; err != nil {
+		return "", false, err
+	}
+
+	list := reflect.Indirect(reflect.ValueOf(out))
+	if list.Kind() != reflect.Slice {
+		return "", false, errors.New("sqls: cursor paging requires a slice pointer")
+	}
+	if list.Len() > s.CursorPaging.Limit {
+		hasMore = true
+		list.Set(list.Slice(0, s.CursorPaging.Limit))
+	}
+	if list.Len() == 0 {
+		return s.CursorPaging.Cursor, false, nil
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if list.Type().Elem().Kind() != reflect.Map {
+		if err = stmt.Parse(out); err != nil {
+			return "", false, err
+		}
+	}
+
+	last := reflect.Indirect(list.Index(list.Len() - 1))
+	if last.Kind() == reflect.Interface {
+		last = reflect.Indirect(last.Elem())
+	}
+	values := make([]interface{}, len(s.Orders))
+	for i, order := range s.Orders {
+		name := cursorColumnName(order.Column)
+		if last.Kind() == reflect.Map {
+			value := last.MapIndex(reflect.ValueOf(name))
+			if !value.IsValid() {
+				return "", false, errors.New("sqls: cursor column not found in result: " + name)
+			}
+			values[i] = value.Interface()
+		} else if field := stmt.Schema.LookUpField(name); field != nil {
+			values[i], _ = field.ValueOf(db.Statement.Context, last)
+		} else {
+			return "", false, errors.New("sqls: cursor column not found in result: " + name)
+		}
+	}
+	cursor, err = s.encodeCursor(values)
+	return
+}
+
+// buildCursor 根据游标生成 keyset 条件：(c1 > v1) OR (c1 = v1 AND c2 > v2) ...，倒序时使用 <
+func (s *Cnd) buildCursor(db *gorm.DB) *gorm.DB {
+	if s.CursorPaging == nil || s.CursorPaging.Cursor == "" {
+		return db
+	}
+	values, err := s.decodeCursor(s.CursorPaging.Cursor)
+	if err != nil {
+		return withError(db, err)
+	}
+
+	var (
+		dialect = DialectOf(db)
+		columns = make([]string, len(s.Orders))
+		queries []string
+		args    []interface{}
+	)
+	for i, order := range s.Orders {
+		columns[i], _, _ = order.build(dialect)
+	}
+	for i, order := range s.Orders {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = ?")
+			args = append(args, values[j])
+		}
+		if order.Asc {
+			parts = append(parts, columns[i]+" > ?")
+		} else {
+			parts = append(parts, columns[i]+" < ?")
+		}
+		args = append(args, values[i])
+		queries = append(queries, "("+strings.Join(parts, " AND ")+")")
+	}
+	return db.Where("("+strings.Join(queries, " OR ")+")", args...)
+}
+
+type cursorPayload struct {
+	Columns []string      `json:"c"`
+	Values  []cursorValue `json:"v"`
+}
+
+type cursorValue struct {
+	Type  string      `json:"t,omitempty"`
+	Value interface{} `json:"v"`
+}
+
+func (s *Cnd) orderColumns() []string {
+	columns := make([]string, len(s.Orders))
+	for i, order := range s.Orders {
+		columns[i] = order.Column
+	}
+	return columns
+}
+
+func (s *Cnd) encodeCursor(values []interface{}) (string, error) {
+	payload := cursorPayload{Columns: s.orderColumns()}
+	for _, value := range values {
+		if valuer, ok := value.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return "", err
+			}
+			value = v
+		}
+		if t, ok := value.(time.Time); ok {
+			payload.Values = append(payload.Values, cursorValue{Type: "time", Value: t.Format(time.RFC3339Nano)})
+		} else {
+			payload.Values = append(payload.Values, cursorValue{Value: value})
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(cursorSign(data)), nil
+}
+
+func (s *Cnd) decodeCursor(cursor string) ([]interface{}, error) {
+	if len(s.Orders) == 0 {
+		return nil, ErrCursorNoOrders
+	}
+	encoded, sign, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sum, err := base64.RawURLEncoding.DecodeString(sign)
+	if err != nil || !hmac.Equal(sum, cursorSign(data)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	// 游标必须与当前排序一致
+	if strings.Join(payload.Columns, ",") != strings.Join(s.orderColumns(), ",") || len(payload.Values) != len(s.Orders) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(payload.Values))
+	for i, v := range payload.Values {
+		switch value := v.Value.(type) {
+		case json.Number:
+			if n, err := value.Int64(); err == nil {
+				values[i] = n
+			} else if f, err := value.Float64(); err == nil {
+				values[i] = f
+			} else {
+				return nil, ErrInvalidCursor
+			}
+		case string:
+			if v.Type == "time" {
+				t, err := time.Parse(time.RFC3339Nano, value)
+				if err != nil {
+					return nil, ErrInvalidCursor
+				}
+				values[i] = t
+			} else {
+				values[i] = value
+			}
+		default:
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+func cursorSign(data []byte) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(data)
+	return mac.Sum(nil)[:16]
+}
+
+// cursorColumnName 去掉排序字段的引号和表名前缀，例如：u.create_time、`u`.`create_time` => create_time
+func cursorColumnName(column string) string {
+	if idx := strings.LastIndex(column, "."); idx >= 0 {
+		column = column[idx+1:]
+	}
+	return strings.Trim(column, "`\"[]")
+}
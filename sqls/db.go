@@ -1,6 +1,11 @@
 package sqls
 
 import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+
 	"gorm.io/gorm"
 )
 
@@ -16,14 +21,141 @@ type DbConfig struct {
 	ConnMaxLifetimeSeconds int    `yaml:"connMaxLifetimeSeconds"`
 }
 
+// DefaultDataSource 默认数据源名称，DB()、SetDB 使用该数据源
+const DefaultDataSource = "default"
+
+const (
+	forcePrimaryKey   = "sqls:force_primary"
+	originConnPoolKey = "sqls:origin_conn_pool"
+)
+
+// dataSource 数据源，包含主库和只读副本
+type dataSource struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	next     atomic.Uint64
+}
+
 var (
-	_db *gorm.DB
+	dataSourcesMu sync.RWMutex
+	dataSources   = map[string]*dataSource{}
 )
 
 func DB() *gorm.DB {
-	return _db
+	return Use(DefaultDataSource)
 }
 
 func SetDB(gormDB *gorm.DB) {
-	_db = gormDB
+	_ = Register(DefaultDataSource, gormDB)
+}
+
+// Use 获取指定名称数据源的主库，查询会按 Register 的配置路由到只读副本，数据源不存在时返回 nil
+func Use(name string) *gorm.DB {
+	dataSourcesMu.RLock()
+	defer dataSourcesMu.RUnlock()
+	if ds, ok := dataSources[name]; ok {
+		return ds.primary
+	}
+	return nil
+}
+
+// Register 注册数据源，同名数据源会被覆盖。
+// 配置了只读副本时，通过主库执行的查询（Find、First、Count、Scan 等，包括 Cnd.Find、Cnd.Count）
+// 轮询路由到副本；写入以及事务中的语句使用主库，需要读到刚写入的数据时使用 ForcePrimary
+func Register(name string, primary *gorm.DB, replicas ...*gorm.DB) error {
+	if primary == nil {
+		return errors.New("sqls: primary db is nil")
+	}
+	ds := &dataSource{primary: primary, replicas: replicas}
+	if len(replicas) > 0 {
+		if err := registerReplicaCallbacks(name, primary); err != nil {
+			return err
+		}
+	}
+
+	dataSourcesMu.Lock()
+	defer dataSourcesMu.Unlock()
+	dataSources[name] = ds
+	return nil
+}
+
+// ForcePrimary 强制后续查询使用主库，用于写后读，例如：cnd.Find(sqls.ForcePrimary(sqls.DB()), &list)
+func ForcePrimary(db *gorm.DB) *gorm.DB {
+	return db.Set(forcePrimaryKey, true)
+}
+
+func registerReplicaCallbacks(name string, primary *gorm.DB) error {
+	route := func(db *gorm.DB) {
+		dataSourcesMu.RLock()
+		ds := dataSources[name]
+		dataSourcesMu.RUnlock()
+		if ds != nil && ds.primary.Config.ConnPool == db.Config.ConnPool {
+			ds.route(db)
+		}
+	}
+
+	var (
+		routeName   = "sqls:replica:" + name
+		restoreName = "sqls:replica_restore:" + name
+		query       = primary.Callback().Query()
+		row         = primary.Callback().Row()
+	)
+	if err := registerCallback(query.Before("gorm:query"), query, routeName, route); err != nil {
+		return err
+	}
+	if err := registerCallback(query.After("gorm:query"), query, restoreName, restoreConnPool); err != nil {
+		return err
+	}
+	if err := registerCallback(row.Before("gorm:row"), row, routeName, route); err != nil {
+		return err
+	}
+	return registerCallback(row.After("gorm:row"), row, restoreName, restoreConnPool)
+}
+
+type callbackRegister interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+type callbackProcessor interface {
+	Get(name string) func(*gorm.DB)
+	Replace(name string, fn func(*gorm.DB)) error
+}
+
+// registerCallback 注册回调，已存在时替换
+func registerCallback(register callbackRegister, processor callbackProcessor, name string, fn func(*gorm.DB)) error {
+	if processor.Get(name) != nil {
+		return processor.Replace(name, fn)
+	}
+	return register.Register(name, fn)
+}
+
+// restoreConnPool 查询结束后恢复主库连接，避免同一个 Statement 后续的写入使用副本
+func restoreConnPool(db *gorm.DB) {
+	if pool, ok := db.Statement.Settings.LoadAndDelete(originConnPoolKey); ok {
+		db.Statement.ConnPool = pool.(gorm.ConnPool)
+	}
+}
+
+// route 将查询路由到只读副本
+func (ds *dataSource) route(db *gorm.DB) {
+	if len(ds.replicas) == 0 || db.Error != nil {
+		return
+	}
+	if force, ok := db.Get(forcePrimaryKey); ok && force == true {
+		return
+	}
+	// 事务中使用主库
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	// Raw 执行的非查询语句使用主库
+	if sql := strings.TrimSpace(db.Statement.SQL.String()); sql != "" {
+		keyword, _, _ := strings.Cut(sql, " ")
+		if !strings.EqualFold(keyword, "SELECT") && !strings.EqualFold(keyword, "WITH") {
+			return
+		}
+	}
+	replica := ds.replicas[(ds.next.Add(1)-1)%uint64(len(ds.replicas))]
+	db.Statement.Settings.Store(originConnPoolKey, db.Statement.ConnPool)
+	db.Statement.ConnPool = replica.Config.ConnPool
 }
@@ -0,0 +1,66 @@
+package sqls_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openFileDB(t *testing.T, name string, users ...string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), name)), &gorm.Config{Logger: logger.Discard})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&CndUser{}))
+	for _, user := range users {
+		assert.NoError(t, db.Create(&CndUser{Name: user}).Error)
+	}
+	return db
+}
+
+func userNames(t *testing.T, db *gorm.DB) (names []string) {
+	var list []CndUser
+	assert.NoError(t, sqls.NewCnd().Asc("id").FindCtx(t.Context(), db, &list))
+	for _, user := range list {
+		names = append(names, user.Name)
+	}
+	return
+}
+
+// 测试命名数据源及读写分离
+func TestRegister_ReadReplica(t *testing.T) {
+	var (
+		primary = openFileDB(t, "primary.db", "primary")
+		replica = openFileDB(t, "replica.db", "replica")
+	)
+	assert.NoError(t, sqls.Register("orders", primary, replica))
+	db := sqls.Use("orders")
+	assert.Same(t, primary, db)
+	assert.Nil(t, sqls.Use("none"))
+
+	// 查询路由到副本
+	assert.Equal(t, []string{"replica"}, userNames(t, db))
+	assert.Equal(t, int64(1), sqls.NewCnd().Eq("name", "replica").Count(db, &CndUser{}))
+
+	// 写入使用主库，强制主库读取
+	assert.NoError(t, db.Create(&CndUser{Name: "new"}).Error)
+	assert.Equal(t, []string{"primary", "new"}, userNames(t, sqls.ForcePrimary(db)))
+
+	// 事务中使用主库
+	err := sqls.WithTransactionOn(db, func(ctx *sqls.TxContext) error {
+		assert.Equal(t, []string{"primary", "new"}, userNames(t, ctx.Tx))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+// 测试默认数据源
+func TestSetDB(t *testing.T) {
+	db := openFileDB(t, "default.db")
+	sqls.SetDB(db)
+	assert.Same(t, db, sqls.DB())
+	assert.Same(t, db, sqls.Use(sqls.DefaultDataSource))
+}
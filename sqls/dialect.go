@@ -0,0 +1,429 @@
+package sqls
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/YspCoder/simple/common/jsons"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ArrayOp 数组列操作
+type ArrayOp int
+
+const (
+	ArrayContainsAll ArrayOp = iota // 数组列包含全部值
+	ArrayOverlaps                   // 数组列与值有交集
+	ArrayContainedBy                // 数组列被值包含
+	ArrayAnyEqual                   // 单值在数组列中
+	ArrayNotInAll                   // 单值不在数组列中
+)
+
+// Dialect SQL 方言，屏蔽不同数据库之间的语法差异。
+// 没有原生数组类型的数据库，数组列以 JSON 数组存储。
+type Dialect interface {
+	// Name 方言名称，与 gorm.Dialector.Name() 一致
+	Name() string
+	// Quote 为单个标识符加引号，标识符中的引号需要转义
+	Quote(identifier string) string
+	// Like 生成 LIKE 条件，ignoreCase 为 true 时大小写不敏感，包含一个 ? 占位符，
+	// 以 LikeEscape 作为转义字符，参数中的通配符需要使用 EscapeLike 转义
+	Like(column string, ignoreCase bool) string
+	// Concat 字符串拼接
+	Concat(exprs ...string) string
+	// FindInSet 逗号分隔的字符串列中是否包含某个值，包含一个 ? 占位符
+	FindInSet(column string) string
+	// Array 数组列操作，ArrayAnyEqual、ArrayNotInAll 的 value 为单个值，其他操作为 []string，返回条件及其唯一参数
+	Array(column string, op ArrayOp, value interface{}) (string, interface{})
+	// MaxPlaceholders 单条语句允许的最大占位符数量
+	MaxPlaceholders() int
+	// UpsertCounts 根据 upsert 的行数及影响行数计算新增、更新的行数，驱动无法区分时 ok 为 false
+	UpsertCounts(rows, affected int64) (inserted, updated int64, ok bool)
+	// Match 全文检索，columns 为未加引号的列名，返回条件、相关度表达式（越大越相关，不支持时为空）
+	// 及两者共用的唯一参数
+	Match(columns []string, query string) (cond, score string, arg interface{}, err error)
+	// Json JSON 列操作，path 为拆分后的路径（纯数字表示数组下标），JsonHasKey 忽略 value，返回条件及参数
+	Json(column string, op JsonOp, path []string, value interface{}) (string, []interface{})
+	// Locking 行锁子句，strength 为 clause.LockingStrengthUpdate 等，不支持时 ok 为 false
+	Locking(strength, options string) (locking clause.Expression, ok bool)
+	// IndexHints 跟在表名后的索引提示，不支持时 ok 为 false
+	IndexHints(hints []IndexHint) (expr string, ok bool)
+}
+
+// LikeEscape LIKE 条件的转义字符，不使用反斜杠以避免各数据库字符串字面量中反斜杠的差异
+const LikeEscape = "!"
+
+var likeEscaper = strings.NewReplacer(LikeEscape, LikeEscape+LikeEscape, "%", LikeEscape+"%", "_", LikeEscape+"_", "[", LikeEscape+"[")
+
+// EscapeLike 转义 LIKE 参数中的通配符（%、_ 及 SQL Server 的 [），使其按字面匹配
+func EscapeLike(str string) string {
+	return likeEscaper.Replace(str)
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{}
+)
+
+func init() {
+	RegisterDialect(MySQLDialect{})
+	RegisterDialect(PostgresDialect{})
+	RegisterDialect(SQLiteDialect{})
+	RegisterDialect(SQLServerDialect{})
+}
+
+// RegisterDialect 注册方言，同名方言会被覆盖
+func RegisterDialect(dialect Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+	dialects[dialect.Name()] = dialect
+}
+
+// DialectOf 获取数据库对应的方言，无法识别时使用 MySQL 方言
+func DialectOf(db *gorm.DB) Dialect {
+	name := ""
+	if db != nil && db.Dialector != nil {
+		name = db.Dialector.Name()
+	}
+
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+	if dialect, ok := dialects[name]; ok {
+		return dialect
+	}
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "postgre"):
+		return dialects[PostgresDialect{}.Name()]
+	case strings.Contains(lower, "sqlite"):
+		return dialects[SQLiteDialect{}.Name()]
+	case strings.Contains(lower, "sqlserver"), strings.Contains(lower, "mssql"):
+		return dialects[SQLServerDialect{}.Name()]
+	}
+	return dialects[MySQLDialect{}.Name()]
+}
+
+// CurrentDialect 当前 DB() 对应的方言
+func CurrentDialect() Dialect {
+	return DialectOf(DB())
+}
+
+// dialectExpr 依赖方言的 SQL 片段，作为 Cnd 条件的参数保存，执行查询时才根据 db 的方言生成，见 resolveArgs。
+// 因此同一个 Cnd 可以用于不同数据库的数据源，例如 Use("reporting")
+type dialectExpr func(d Dialect) (string, []interface{}, error)
+
+// columnExpr 列名，执行查询时按 db 的方言加引号
+func columnExpr(column string) dialectExpr {
+	return func(d Dialect) (string, []interface{}, error) {
+		return wrapKeyword(d, column), nil, nil
+	}
+}
+
+// MySQLDialect MySQL 方言，数组列以 JSON 数组存储
+type MySQLDialect struct{}
+
+func (MySQLDialect) Name() string {
+	return "mysql"
+}
+
+func (MySQLDialect) Quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (MySQLDialect) Like(column string, ignoreCase bool) string {
+	if ignoreCase {
+		return "LOWER(" + column + ") LIKE LOWER(?) ESCAPE '" + LikeEscape + "'"
+	}
+	return column + " LIKE ? ESCAPE '" + LikeEscape + "'"
+}
+
+func (MySQLDialect) Concat(exprs ...string) string {
+	return "CONCAT(" + strings.Join(exprs, ", ") + ")"
+}
+
+func (MySQLDialect) FindInSet(column string) string {
+	return "FIND_IN_SET(?, " + column + ") > 0"
+}
+
+func (MySQLDialect) Array(column string, op ArrayOp, value interface{}) (string, interface{}) {
+	switch op {
+	case ArrayOverlaps:
+		return "JSON_OVERLAPS(" + column + ", ?)", jsons.ToJsonStr(value)
+	case ArrayContainedBy:
+		return "JSON_CONTAINS(?, " + column + ")", jsons.ToJsonStr(value)
+	case ArrayAnyEqual:
+		return "JSON_CONTAINS(" + column + ", JSON_ARRAY(?))", value
+	case ArrayNotInAll:
+		return "NOT JSON_CONTAINS(" + column + ", JSON_ARRAY(?))", value
+	default:
+		return "JSON_CONTAINS(" + column + ", ?)", jsons.ToJsonStr(value)
+	}
+}
+
+func (MySQLDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (MySQLDialect) UpsertCounts(rows, affected int64) (int64, int64, bool) {
+	// ON DUPLICATE KEY UPDATE 新增的行影响行数为 1，更新的行为 2，值未变化的行为 0，存在未变化的行时计数为近似值
+	updated := max(affected-rows, 0)
+	return rows - updated, updated, true
+}
+
+func (d MySQLDialect) Match(columns []string, query string) (string, string, interface{}, error) {
+	match := "MATCH (" + quoteColumns(d, columns) + ") AGAINST (? IN NATURAL LANGUAGE MODE)"
+	return match, match, query, nil
+}
+
+// PostgresDialect Postgres 方言，数组列为原生 text[]
+type PostgresDialect struct {
+	// TextSearchConfig 全文检索使用的配置，例如：english，为空时使用数据库的 default_text_search_config。
+	// 需要使用表达式索引时应当设置，并使用相同的表达式创建索引，例如：
+	// CREATE INDEX ON posts USING GIN (to_tsvector('english', coalesce(title, '') || ' ' || coalesce(body, '')))
+	TextSearchConfig string
+}
+
+func (PostgresDialect) Name() string {
+	return "postgres"
+}
+
+func (PostgresDialect) Quote(identifier string) string {
+	return "\"" + strings.ReplaceAll(identifier, "\"", "\"\"") + "\""
+}
+
+func (PostgresDialect) Like(column string, ignoreCase bool) string {
+	if ignoreCase {
+		return column + " ILIKE ? ESCAPE '" + LikeEscape + "'"
+	}
+	return column + " LIKE ? ESCAPE '" + LikeEscape + "'"
+}
+
+func (PostgresDialect) Concat(exprs ...string) string {
+	return "CONCAT(" + strings.Join(exprs, ", ") + ")"
+}
+
+func (PostgresDialect) FindInSet(column string) string {
+	return "? = ANY(string_to_array(" + column + ", ','))"
+}
+
+func (PostgresDialect) Array(column string, op ArrayOp, value interface{}) (string, interface{}) {
+	switch op {
+	case ArrayOverlaps:
+		return column + " && ?::text[]", pgTextArray(toStrings(value))
+	case ArrayContainedBy:
+		return column + " <@ ?::text[]", pgTextArray(toStrings(value))
+	case ArrayAnyEqual:
+		return "? = ANY(" + column + ")", value
+	case ArrayNotInAll:
+		return "? <> ALL(" + column + ")", value
+	default:
+		return column + " @> ?::text[]", pgTextArray(toStrings(value))
+	}
+}
+
+func (PostgresDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+func (PostgresDialect) UpsertCounts(rows, affected int64) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+func (d PostgresDialect) Match(columns []string, query string) (string, string, interface{}, error) {
+	config := ""
+	if d.TextSearchConfig != "" {
+		config = "'" + strings.ReplaceAll(d.TextSearchConfig, "'", "''") + "', "
+	}
+	docs := make([]string, len(columns))
+	for i, column := range columns {
+		docs[i] = "coalesce(" + quoteColumn(d, column) + ", '')"
+	}
+	vector := "to_tsvector(" + config + strings.Join(docs, " || ' ' || ") + ")"
+	tsquery := "plainto_tsquery(" + config + "?)"
+	return vector + " @@ " + tsquery, "ts_rank(" + vector + ", " + tsquery + ")", query, nil
+}
+
+// SQLiteDialect SQLite 方言，数组列以 JSON 数组存储，通过 json_each 模拟数组操作
+type SQLiteDialect struct{}
+
+func (SQLiteDialect) Name() string {
+	return "sqlite"
+}
+
+func (SQLiteDialect) Quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (SQLiteDialect) Like(column string, ignoreCase bool) string {
+	if ignoreCase {
+		return "LOWER(" + column + ") LIKE LOWER(?) ESCAPE '" + LikeEscape + "'"
+	}
+	return column + " LIKE ? ESCAPE '" + LikeEscape + "'"
+}
+
+func (SQLiteDialect) Concat(exprs ...string) string {
+	return "(" + strings.Join(exprs, " || ") + ")"
+}
+
+// FindInSet 使用 instr 而不是 LIKE，避免值中的通配符
+func (d SQLiteDialect) FindInSet(column string) string {
+	return "instr(" + d.Concat("','", column, "','") + ", " + d.Concat("','", "?", "','") + ") > 0"
+}
+
+func (SQLiteDialect) Array(column string, op ArrayOp, value interface{}) (string, interface{}) {
+	return jsonEachArray("json_each", "value", column, op, value)
+}
+
+func (SQLiteDialect) MaxPlaceholders() int {
+	return 32766
+}
+
+func (SQLiteDialect) UpsertCounts(rows, affected int64) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+// Match 使用 FTS5 虚拟表检索，列名需要带上虚拟表名（例如：posts.title）以便在多列中检索，
+// 只检索一列时可以不带表名。查询按空白拆分为多个短语，全部匹配时才满足条件
+func (d SQLiteDialect) Match(columns []string, query string) (string, string, interface{}, error) {
+	var (
+		table string
+		names = make([]string, len(columns))
+	)
+	for i, column := range columns {
+		prefix, name := "", column
+		if idx := strings.LastIndex(column, "."); idx >= 0 {
+			prefix, name = column[:idx], column[idx+1:]
+		}
+		if i > 0 && prefix != table {
+			return "", "", nil, errors.New("sqls: sqlite full-text search columns must belong to the same fts5 table")
+		}
+		table, names[i] = prefix, name
+	}
+
+	phrases := strings.Fields(query)
+	for i, phrase := range phrases {
+		phrases[i] = `"` + strings.ReplaceAll(phrase, `"`, `""`) + `"`
+	}
+	ftsQuery := strings.Join(phrases, " ")
+
+	if table == "" {
+		if len(columns) > 1 {
+			return "", "", nil, errors.New("sqls: sqlite full-text search on multiple columns requires the fts5 table name, e.g. posts.title")
+		}
+		return quoteColumn(d, columns[0]) + " MATCH ?", "-rank", ftsQuery, nil
+	}
+	ftsQuery = "{" + strings.Join(names, " ") + "} : " + ftsQuery
+	return quoteColumn(d, table) + " MATCH ?", "-bm25(" + quoteColumn(d, table) + ")", ftsQuery, nil
+}
+
+// SQLServerDialect SQL Server 方言，数组列以 JSON 数组存储，通过 OPENJSON 模拟数组操作
+type SQLServerDialect struct{}
+
+func (SQLServerDialect) Name() string {
+	return "sqlserver"
+}
+
+func (SQLServerDialect) Quote(identifier string) string {
+	return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]"
+}
+
+func (SQLServerDialect) Like(column string, ignoreCase bool) string {
+	if ignoreCase {
+		return "LOWER(" + column + ") LIKE LOWER(?) ESCAPE '" + LikeEscape + "'"
+	}
+	return column + " LIKE ? ESCAPE '" + LikeEscape + "'"
+}
+
+func (SQLServerDialect) Concat(exprs ...string) string {
+	return "CONCAT(" + strings.Join(exprs, ", ") + ")"
+}
+
+// FindInSet 使用 CHARINDEX 而不是 LIKE，避免值中的通配符
+func (d SQLServerDialect) FindInSet(column string) string {
+	return "CHARINDEX(" + d.Concat("','", "?", "','") + ", " + d.Concat("','", column, "','") + ") > 0"
+}
+
+func (SQLServerDialect) Array(column string, op ArrayOp, value interface{}) (string, interface{}) {
+	return jsonEachArray("OPENJSON", "[value]", column, op, value)
+}
+
+func (SQLServerDialect) MaxPlaceholders() int {
+	return 2100
+}
+
+func (SQLServerDialect) UpsertCounts(rows, affected int64) (int64, int64, bool) {
+	return 0, 0, false
+}
+
+// Match 使用 FREETEXT 检索，需要列上已建立全文索引；相关度需要 FREETEXTTABLE，不支持
+func (d SQLServerDialect) Match(columns []string, query string) (string, string, interface{}, error) {
+	return "FREETEXT((" + quoteColumns(d, columns) + "), ?)", "", query, nil
+}
+
+// jsonEachArray 使用表值函数（json_each、OPENJSON）展开 JSON 数组模拟数组操作
+func jsonEachArray(fn, valueCol, column string, op ArrayOp, value interface{}) (string, interface{}) {
+	colValue, argValue := "a."+valueCol, "b."+valueCol
+	switch op {
+	case ArrayOverlaps:
+		return "EXISTS (SELECT 1 FROM " + fn + "(" + column + ") a, " + fn + "(?) b WHERE " + colValue + " = " + argValue + ")",
+			jsons.ToJsonStr(value)
+	case ArrayContainedBy:
+		return "NOT EXISTS (SELECT 1 FROM " + fn + "(" + column + ") a WHERE NOT EXISTS (SELECT 1 FROM " + fn + "(?) b WHERE " + argValue + " = " + colValue + "))",
+			jsons.ToJsonStr(value)
+	case ArrayAnyEqual:
+		return "EXISTS (SELECT 1 FROM " + fn + "(" + column + ") a WHERE " + colValue + " = ?)", value
+	case ArrayNotInAll:
+		return "NOT EXISTS (SELECT 1 FROM " + fn + "(" + column + ") a WHERE " + colValue + " = ?)", value
+	default:
+		return "NOT EXISTS (SELECT 1 FROM " + fn + "(?) b WHERE NOT EXISTS (SELECT 1 FROM " + fn + "(" + column + ") a WHERE " + colValue + " = " + argValue + "))",
+			jsons.ToJsonStr(value)
+	}
+}
+
+// pgTextArray Postgres text[] 字面量，例如：{"a","b"}
+type pgTextArray []string
+
+func (a pgTextArray) Value() (driver.Value, error) {
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, s := range a {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('"')
+		sb.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String(), nil
+}
+
+// quoteColumn 为 table.column 形式的列名的各部分加引号
+func quoteColumn(d Dialect, column string) string {
+	parts := strings.Split(column, ".")
+	for i, part := range parts {
+		parts[i] = d.Quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func quoteColumns(d Dialect, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteColumn(d, column)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package sqls
+
+import (
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// IndexHintType 索引提示类型
+type IndexHintType string
+
+const (
+	UseIndex    IndexHintType = "USE"
+	ForceIndex  IndexHintType = "FORCE"
+	IgnoreIndex IndexHintType = "IGNORE"
+)
+
+// IndexHint 索引提示
+type IndexHint struct {
+	Type    IndexHintType
+	Indexes []string
+}
+
+func (MySQLDialect) Locking(strength, options string) (clause.Expression, bool) {
+	return clause.Locking{Strength: strength, Options: options}, true
+}
+
+// IndexHints 例如：USE INDEX (`idx_status`) IGNORE INDEX (`idx_name`)
+func (d MySQLDialect) IndexHints(hints []IndexHint) (string, bool) {
+	var sb strings.Builder
+	for i, hint := range hints {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(string(hint.Type) + " INDEX (")
+		for j, index := range hint.Indexes {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(d.Quote(index))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String(), true
+}
+
+func (PostgresDialect) Locking(strength, options string) (clause.Expression, bool) {
+	return clause.Locking{Strength: strength, Options: options}, true
+}
+
+func (PostgresDialect) IndexHints(hints []IndexHint) (string, bool) {
+	return "", false
+}
+
+func (SQLiteDialect) Locking(strength, options string) (clause.Expression, bool) {
+	return nil, false
+}
+
+func (SQLiteDialect) IndexHints(hints []IndexHint) (string, bool) {
+	return "", false
+}
+
+// Locking SQL Server 使用表提示（UPDLOCK、READPAST 等）加锁，不支持 FOR 子句
+func (SQLServerDialect) Locking(strength, options string) (clause.Expression, bool) {
+	return nil, false
+}
+
+func (SQLServerDialect) IndexHints(hints []IndexHint) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,209 @@
+package sqls
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/YspCoder/simple/common/jsons"
+)
+
+// JsonOp JSON 列操作
+type JsonOp int
+
+const (
+	JsonEq            JsonOp = iota // 路径上的值等于 value
+	JsonContains                    // 路径上的 JSON 包含 value（对象包含全部键值、数组包含全部元素）
+	JsonHasKey                      // 路径存在
+	JsonArrayContains               // 路径上的数组包含单个值 value
+)
+
+func (MySQLDialect) Json(column string, op JsonOp, path []string, value interface{}) (string, []interface{}) {
+	jsonPath := jsonPathString(path)
+	switch op {
+	case JsonContains, JsonArrayContains:
+		return "JSON_CONTAINS(" + column + ", ?, ?)", []interface{}{jsons.ToJsonStr(value), jsonPath}
+	case JsonHasKey:
+		return "JSON_CONTAINS_PATH(" + column + ", 'one', ?)", []interface{}{jsonPath}
+	default:
+		return "JSON_UNQUOTE(JSON_EXTRACT(" + column + ", ?)) = ?", []interface{}{jsonPath, jsonText(value)}
+	}
+}
+
+// Json Postgres 中 JSON 列需要是 jsonb 类型
+func (PostgresDialect) Json(column string, op JsonOp, path []string, value interface{}) (string, []interface{}) {
+	var (
+		expr = column
+		args = make([]interface{}, 0, len(path)+1)
+	)
+	for i, key := range path {
+		arrow := " -> "
+		if op == JsonEq && i == len(path)-1 {
+			arrow = " ->> "
+		}
+		// 未指定类型的参数会被当作 text 即对象的键，数组下标已校验为纯数字，直接作为整数字面量
+		if isJsonIndex(key) {
+			expr += arrow + key
+		} else {
+			expr += arrow + "?"
+			args = append(args, key)
+		}
+	}
+
+	switch op {
+	case JsonContains:
+		return "(" + expr + ") @> ?::jsonb", append(args, jsons.ToJsonStr(value))
+	case JsonArrayContains:
+		return "(" + expr + ") @> ?::jsonb", append(args, jsons.ToJsonStr([]interface{}{value}))
+	case JsonHasKey:
+		// -> 对值为 JSON null 的键返回 'null'::jsonb，只有路径不存在时才是 SQL NULL
+		return "(" + expr + ") IS NOT NULL", args
+	default:
+		if len(path) == 0 {
+			expr += " #>> '{}'"
+		}
+		return "(" + expr + ") = ?", append(args, jsonText(value))
+	}
+}
+
+func (SQLiteDialect) Json(column string, op JsonOp, path []string, value interface{}) (string, []interface{}) {
+	return jsonFuncs{
+		extract:  "json_extract",
+		each:     "json_each",
+		valueCol: "value",
+		hasKey:   "json_type(" + column + ", ?) IS NOT NULL",
+		castText: true,
+		scalar: func(value interface{}) (string, interface{}) {
+			// 通过 json_extract 解析参数，使布尔、数字与列中的值类型一致
+			return "json_extract(?, '$')", jsons.ToJsonStr(value)
+		},
+	}.build(column, op, path, value)
+}
+
+// Json SQL Server 中 JsonHasKey 需要 SQL Server 2022 及以上版本
+func (SQLServerDialect) Json(column string, op JsonOp, path []string, value interface{}) (string, []interface{}) {
+	return jsonFuncs{
+		extract:  "JSON_VALUE",
+		each:     "OPENJSON",
+		valueCol: "[value]",
+		hasKey:   "JSON_PATH_EXISTS(" + column + ", ?) = 1",
+		scalar: func(value interface{}) (string, interface{}) {
+			return "?", jsonText(value)
+		},
+	}.build(column, op, path, value)
+}
+
+// jsonFuncs 使用 JSON 函数及表值函数（json_each、OPENJSON）模拟 JSON 操作
+type jsonFuncs struct {
+	extract  string // 取路径上的标量值的函数
+	each     string // 展开 JSON 数组的表值函数
+	valueCol string // 表值函数的值列
+	hasKey   string // 路径是否存在的条件，包含一个路径占位符
+	castText bool   // 与字符串比较时是否将取到的值转换为文本，与其他数据库的行为保持一致
+	scalar   func(value interface{}) (string, interface{})
+}
+
+func (f jsonFuncs) build(column string, op JsonOp, path []string, value interface{}) (string, []interface{}) {
+	jsonPath := jsonPathString(path)
+	switch op {
+	case JsonHasKey:
+		return f.hasKey, []interface{}{jsonPath}
+	case JsonArrayContains:
+		return f.arrayContains(column, jsonPath, value)
+	case JsonContains:
+		return f.contains(column, path, value)
+	default:
+		extract := f.extract + "(" + column + ", ?)"
+		if str, ok := value.(string); ok && f.castText {
+			return "CAST(" + extract + " AS TEXT) = ?", []interface{}{jsonPath, str}
+		}
+		placeholder, arg := f.scalar(value)
+		return extract + " = " + placeholder, []interface{}{jsonPath, arg}
+	}
+}
+
+func (f jsonFuncs) arrayContains(column, jsonPath string, value interface{}) (string, []interface{}) {
+	placeholder, arg := f.scalar(value)
+	return "EXISTS (SELECT 1 FROM " + f.each + "(" + column + ", ?) a WHERE a." + f.valueCol + " = " + placeholder + ")",
+		[]interface{}{jsonPath, arg}
+}
+
+func (f jsonFuncs) contains(column string, path []string, value interface{}) (string, []interface{}) {
+	// 统一转换为 JSON 解析后的类型，以便处理各种 map、slice
+	var doc interface{}
+	if err := jsons.Parse(jsons.ToJsonStr(value), &doc); err != nil {
+		doc = value
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		// 对象：逐个比较键值（只比较第一层）
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var (
+			parts = make([]string, 0, len(keys))
+			args  []interface{}
+		)
+		for _, key := range keys {
+			keyPath := append(append([]string{}, path...), key)
+			part, partArgs := f.build(column, JsonEq, keyPath, v[key])
+			parts = append(parts, part)
+			args = append(args, partArgs...)
+		}
+		if len(parts) == 0 {
+			return f.build(column, JsonHasKey, path, nil)
+		}
+		return "(" + strings.Join(parts, " AND ") + ")", args
+	case []interface{}:
+		// 数组：参数中的每个元素都在列的数组中
+		return "NOT EXISTS (SELECT 1 FROM " + f.each + "(?) b WHERE NOT EXISTS (SELECT 1 FROM " + f.each + "(" + column + ", ?) a WHERE a." + f.valueCol + " = b." + f.valueCol + "))",
+			[]interface{}{jsons.ToJsonStr(v), jsonPathString(path)}
+	default:
+		// 标量：路径上的值相等，或路径上的数组包含该值
+		eq, eqArgs := f.build(column, JsonEq, path, v)
+		contains, containsArgs := f.arrayContains(column, jsonPathString(path), v)
+		return "(" + eq + " OR " + contains + ")", append(eqArgs, containsArgs...)
+	}
+}
+
+// jsonPathString 转换为 $."a"[0]."b" 形式的 JSON 路径
+func jsonPathString(path []string) string {
+	var sb strings.Builder
+	sb.WriteByte('$')
+	for _, key := range path {
+		if isJsonIndex(key) {
+			sb.WriteString("[" + key + "]")
+		} else {
+			sb.WriteString(`."` + key + `"`)
+		}
+	}
+	return sb.String()
+}
+
+func isJsonIndex(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonText 值以文本形式与 JSON 路径上的值比较时的参数
+func jsonText(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,207 @@
+package sqls_test
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type DialectItem struct {
+	ID    int64  `gorm:"primarykey"`
+	Codes string // 逗号分隔
+	Tags  string // JSON 数组
+	Attrs string // JSON 对象
+}
+
+func setupDialectTestDB(t *testing.T) {
+	db := setupCndTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&DialectItem{}))
+	items := []DialectItem{
+		{Codes: "a,b,c", Tags: `["go","sql"]`, Attrs: `{"color":"red","size":1,"address":{"city":"beijing"},"labels":["new","hot"]}`},
+		{Codes: "c,d", Tags: `["go"]`, Attrs: `{"color":"blue","size":2,"labels":["hot"]}`},
+		{Codes: "e", Tags: `["java","sql","go"]`, Attrs: `{"color":"red","address":{"city":"shanghai"},"vip":true}`},
+	}
+	assert.NoError(t, db.Create(&items).Error)
+}
+
+func findItemIds(t *testing.T, cnd *sqls.Cnd) (ids []int64) {
+	var list []DialectItem
+	assert.NoError(t, cnd.Asc("id").FindCtx(t.Context(), sqls.DB(), &list))
+	for _, item := range list {
+		ids = append(ids, item.ID)
+	}
+	return
+}
+
+// 测试方言识别
+func TestDialectOf(t *testing.T) {
+	setupCndTestDB(t)
+	assert.Equal(t, "sqlite", sqls.CurrentDialect().Name())
+	assert.Equal(t, "mysql", sqls.DialectOf(nil).Name())
+}
+
+// 测试各方言生成的语句
+func TestDialect_SQL(t *testing.T) {
+	var (
+		mysql     = sqls.MySQLDialect{}
+		postgres  = sqls.PostgresDialect{}
+		sqlserver = sqls.SQLServerDialect{}
+	)
+	assert.Equal(t, "`name`", mysql.Quote("name"))
+	assert.Equal(t, `"name"`, postgres.Quote("name"))
+	assert.Equal(t, "[name]", sqlserver.Quote("name"))
+
+	assert.Equal(t, `"name" ILIKE ? ESCAPE '!'`, postgres.Like(`"name"`, true))
+	assert.Equal(t, "LOWER(`name`) LIKE LOWER(?) ESCAPE '!'", mysql.Like("`name`", true))
+	assert.Equal(t, "FIND_IN_SET(?, `codes`) > 0", mysql.FindInSet("`codes`"))
+
+	query, arg := postgres.Array(`"tags"`, sqls.ArrayContainsAll, []string{"a", `b"c`})
+	assert.Equal(t, `"tags" @> ?::text[]`, query)
+	value, err := arg.(driver.Valuer).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a","b\"c"}`, value)
+
+	query, arg = mysql.Array("`tags`", sqls.ArrayOverlaps, []string{"a"})
+	assert.Equal(t, "JSON_OVERLAPS(`tags`, ?)", query)
+	assert.Equal(t, `["a"]`, arg)
+}
+
+// 测试 SQLite 下的 FindInSet 与 json_each 模拟的数组操作
+func TestDialect_SQLite(t *testing.T) {
+	setupDialectTestDB(t)
+
+	assert.Equal(t, []int64{1, 2}, findItemIds(t, sqls.NewCnd().FindInSet("codes", "c")))
+	assert.Equal(t, []int64{3}, findItemIds(t, sqls.NewCnd().NotFindInSet("codes", "c")))
+	assert.Empty(t, findItemIds(t, sqls.NewCnd().FindInSet("codes", "_")))
+
+	assert.Equal(t, []int64{1, 3}, findItemIds(t, sqls.NewCnd().ArrayContainsAll("tags", []string{"sql", "go"})))
+	assert.Equal(t, []int64{1, 3}, findItemIds(t, sqls.NewCnd().ArrayOverlaps("tags", []string{"java", "sql"})))
+	assert.Equal(t, []int64{1, 2}, findItemIds(t, sqls.NewCnd().ArrayContainedBy("tags", []string{"go", "sql"})))
+	assert.Equal(t, []int64{3}, findItemIds(t, sqls.NewCnd().ArrayAnyEqual("tags", "java")))
+	assert.Equal(t, []int64{1, 2}, findItemIds(t, sqls.NewCnd().ArrayNotInAll("tags", "java")))
+}
+
+// 测试各方言的全文检索语句
+func TestDialect_Match(t *testing.T) {
+	cond, score, arg, err := sqls.MySQLDialect{}.Match([]string{"title", "body"}, "go orm")
+	assert.NoError(t, err)
+	assert.Equal(t, "MATCH (`title`, `body`) AGAINST (? IN NATURAL LANGUAGE MODE)", cond)
+	assert.Equal(t, cond, score)
+	assert.Equal(t, "go orm", arg)
+
+	cond, score, _, err = sqls.PostgresDialect{TextSearchConfig: "english"}.Match([]string{"title", "body"}, "go orm")
+	assert.NoError(t, err)
+	assert.Equal(t, `to_tsvector('english', coalesce("title", '') || ' ' || coalesce("body", '')) @@ plainto_tsquery('english', ?)`, cond)
+	assert.Equal(t, `ts_rank(to_tsvector('english', coalesce("title", '') || ' ' || coalesce("body", '')), plainto_tsquery('english', ?))`, score)
+
+	cond, score, arg, err = sqls.SQLiteDialect{}.Match([]string{"posts.title", "posts.body"}, `go "orm`)
+	assert.NoError(t, err)
+	assert.Equal(t, "`posts` MATCH ?", cond)
+	assert.Equal(t, "-bm25(`posts`)", score)
+	assert.Equal(t, `{title body} : "go" """orm"`, arg)
+
+	_, _, _, err = sqls.SQLiteDialect{}.Match([]string{"title", "body"}, "go")
+	assert.Error(t, err)
+}
+
+// 测试全文检索条件及按相关度排序
+func TestCnd_Match(t *testing.T) {
+	db := setupCndTestDB(t)
+	columns := []string{"cnd_users.name", "cnd_users.phone"}
+	cnd := sqls.NewCnd().Match(columns, "tom").Eq("status", 0).OrderByMatch(columns, "tom").Desc("id")
+
+	ret, err := cnd.ToSQL(db.Model(&CndUser{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `cnd_users` WHERE `cnd_users` MATCH ? AND `status` = (?) ORDER BY -bm25(`cnd_users`) DESC, `id` DESC", ret.Select.SQL)
+	assert.Equal(t, []interface{}{`{name phone} : "tom"`, 0, `{name phone} : "tom"`}, ret.Select.Vars)
+
+	assert.Empty(t, sqls.NewCnd().Match(columns, "  ").Params)
+	assert.ErrorIs(t, sqls.NewCnd().Match([]string{"name;"}, "tom").Error, sqls.ErrInvalidColumn)
+}
+
+// 测试各方言的 JSON 操作语句
+func TestDialect_Json(t *testing.T) {
+	query, args := sqls.MySQLDialect{}.Json("`attrs`", sqls.JsonEq, []string{"address", "city"}, "beijing")
+	assert.Equal(t, "JSON_UNQUOTE(JSON_EXTRACT(`attrs`, ?)) = ?", query)
+	assert.Equal(t, []interface{}{`$."address"."city"`, "beijing"}, args)
+
+	query, args = sqls.MySQLDialect{}.Json("`attrs`", sqls.JsonArrayContains, []string{"labels"}, "hot")
+	assert.Equal(t, "JSON_CONTAINS(`attrs`, ?, ?)", query)
+	assert.Equal(t, []interface{}{`"hot"`, `$."labels"`}, args)
+
+	query, args = sqls.PostgresDialect{}.Json(`"attrs"`, sqls.JsonEq, []string{"labels", "0"}, "new")
+	assert.Equal(t, `("attrs" -> ? ->> 0) = ?`, query)
+	assert.Equal(t, []interface{}{"labels", "new"}, args)
+
+	query, args = sqls.PostgresDialect{}.Json(`"attrs"`, sqls.JsonHasKey, []string{"items", "2", "sku"}, nil)
+	assert.Equal(t, `("attrs" -> ? -> 2 -> ?) IS NOT NULL`, query)
+	assert.Equal(t, []interface{}{"items", "sku"}, args)
+
+	query, args = sqls.PostgresDialect{}.Json(`"attrs"`, sqls.JsonContains, nil, map[string]interface{}{"color": "red"})
+	assert.Equal(t, `("attrs") @> ?::jsonb`, query)
+	assert.Equal(t, []interface{}{`{"color":"red"}`}, args)
+
+	query, _ = sqls.PostgresDialect{}.Json(`"attrs"`, sqls.JsonHasKey, []string{"vip"}, nil)
+	assert.Equal(t, `("attrs" -> ?) IS NOT NULL`, query)
+}
+
+// 测试 SQLite 下的 JSON 操作
+func TestCnd_Json(t *testing.T) {
+	setupDialectTestDB(t)
+
+	assert.Equal(t, []int64{1}, findItemIds(t, sqls.NewCnd().JsonEq("attrs", "address.city", "beijing")))
+	assert.Equal(t, []int64{2}, findItemIds(t, sqls.NewCnd().JsonEq("attrs", "size", 2)))
+	assert.Equal(t, []int64{2}, findItemIds(t, sqls.NewCnd().JsonEq("attrs", "size", "2")), "字符串以文本形式比较")
+	assert.Equal(t, []int64{3}, findItemIds(t, sqls.NewCnd().JsonEq("attrs", "vip", true)))
+	assert.Equal(t, []int64{1}, findItemIds(t, sqls.NewCnd().JsonEq("attrs", "labels.0", "new")))
+
+	assert.Equal(t, []int64{1, 3}, findItemIds(t, sqls.NewCnd().JsonHasKey("attrs", "address")))
+	assert.Equal(t, []int64{1, 2}, findItemIds(t, sqls.NewCnd().JsonArrayContains("attrs", "labels", "hot")))
+
+	assert.Equal(t, []int64{1, 3}, findItemIds(t, sqls.NewCnd().JsonContains("attrs", "", map[string]interface{}{"color": "red"})))
+	assert.Equal(t, []int64{1}, findItemIds(t, sqls.NewCnd().JsonContains("attrs", "", map[string]interface{}{"color": "red", "size": 1})))
+	assert.Equal(t, []int64{1}, findItemIds(t, sqls.NewCnd().JsonContains("attrs", "labels", []string{"hot", "new"})))
+	assert.Equal(t, []int64{1, 2}, findItemIds(t, sqls.NewCnd().JsonContains("attrs", "labels", "hot")))
+
+	cnd := sqls.NewCnd().JsonEq("attrs", "address') OR 1=1 --", "x")
+	assert.ErrorIs(t, cnd.Error, sqls.ErrInvalidJsonPath)
+	assert.Empty(t, cnd.Params)
+}
+
+// 测试条件按执行查询的 db 的方言生成，而不是 DB() 的方言
+func TestCnd_DialectOfDB(t *testing.T) {
+	setupCndTestDB(t)
+	pg, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.NoError(t, err)
+
+	cnd := sqls.NewCnd().
+		Cols("u.name", "cnd_orders.amount").
+		InnerJoin("cnd_users", "u", "u.id = cnd_orders.user_id").
+		Eq("u.name", "tom").
+		Like("u.phone", "138").
+		Or(sqls.NewCnd().FindInSet("cnd_orders.day", "x"), sqls.NewCnd().EqCol("cnd_orders.user_id", "u.id")).
+		GroupBy("u.name", "cnd_orders.amount").
+		Desc("cnd_orders.amount")
+	ret, err := cnd.ToSQL(pg.Model(&CndOrder{}))
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT "u"."name","cnd_orders"."amount" FROM "cnd_orders" INNER JOIN "cnd_users" "u" ON u.id = cnd_orders.user_id `+
+		`WHERE "u"."name" = ($1) AND "u"."phone" LIKE $2 ESCAPE '!' `+
+		`AND ((($3 = ANY(string_to_array("cnd_orders"."day", ','))) OR ("cnd_orders"."user_id" = "u"."id"))) `+
+		`GROUP BY "u"."name","cnd_orders"."amount" ORDER BY "cnd_orders"."amount" DESC`, ret.Select.SQL)
+
+	// JSON 数组下标为整数字面量，不作为参数绑定
+	ret, err = sqls.NewCnd().JsonEq("attrs", "labels.0", "new").ToSQL(pg.Model(&DialectItem{}))
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT * FROM "dialect_items" WHERE ("attrs" -> $1 ->> 0) = $2`, ret.Select.SQL)
+	assert.Equal(t, []interface{}{"labels", "new"}, ret.Select.Vars)
+
+	// 同一个 Cnd 在 DB() 上仍使用 SQLite 的方言
+	ret, err = cnd.ToSQL(sqls.DB().Model(&CndOrder{}))
+	assert.NoError(t, err)
+	assert.Contains(t, ret.Select.SQL, "WHERE `u`.`name` = (?) AND `u`.`phone` LIKE ? ESCAPE '!'")
+}
@@ -0,0 +1,158 @@
+package sqls
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidColumn    = errors.New("sqls: invalid column")
+	ErrColumnNotAllowed = errors.New("sqls: column not allowed")
+	ErrInvalidJsonPath  = errors.New("sqls: invalid json path")
+)
+
+// ValidateIdentifier 校验标识符，只允许字母、数字、下划线、$ 组成且不以数字开头的名称，
+// 支持 table.column、table.* 及 column AS alias 形式
+func ValidateIdentifier(name string) error {
+	if idx := strings.LastIndex(strings.ToLower(name), " as "); idx > 0 {
+		if err := ValidateIdentifier(strings.TrimSpace(name[:idx])); err != nil {
+			return err
+		}
+		alias := strings.TrimSpace(name[idx+4:])
+		if !isIdentifierPart(alias) {
+			return fmt.Errorf("%w: %q", ErrInvalidColumn, name)
+		}
+		return nil
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 {
+			continue
+		}
+		if !isIdentifierPart(part) {
+			return fmt.Errorf("%w: %q", ErrInvalidColumn, name)
+		}
+	}
+	return nil
+}
+
+func isIdentifierPart(part string) bool {
+	if part == "" {
+		return false
+	}
+	for i, r := range part {
+		if r == '_' || r == '$' || unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// ParseJsonPath 解析以 . 分隔的 JSON 路径，例如：address.city、tags.0，
+// 每一段只能由字母、数字、下划线、- 组成，空字符串表示根路径
+func ParseJsonPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidJsonPath, path)
+		}
+		for _, r := range key {
+			if r != '_' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidJsonPath, path)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// ModelColumns 获取模型对应的全部列名
+func ModelColumns(db *gorm.DB, model interface{}) ([]string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema.DBNames, nil
+}
+
+// AllowColumns 设置允许使用的列，设置后条件、排序、分组、查询字段只能使用这些列，
+// 需要在添加条件之前调用。带表名或别名的列（如 u.name）按完整名称或列名匹配
+func (s *Cnd) AllowColumns(columns ...string) *Cnd {
+	if s.AllowedCols == nil {
+		s.AllowedCols = make(map[string]bool, len(columns))
+	}
+	for _, column := range columns {
+		s.AllowedCols[column] = true
+	}
+	return s
+}
+
+// AllowModel 将模型的全部列设置为允许使用的列，见 AllowColumns
+func (s *Cnd) AllowModel(db *gorm.DB, model interface{}) *Cnd {
+	columns, err := ModelColumns(db, model)
+	if err != nil {
+		return s.AddError(err)
+	}
+	return s.AllowColumns(columns...)
+}
+
+// AddError 记录构建条件时产生的错误，执行查询时返回该错误
+func (s *Cnd) AddError(err error) *Cnd {
+	if err == nil {
+		return s
+	}
+	if s.Error == nil {
+		s.Error = err
+	} else {
+		s.Error = errors.Join(s.Error, err)
+	}
+	return s
+}
+
+// column 校验列名，校验失败时记录错误并返回 false，调用方应跳过该条件。
+// 返回的列名未加引号，执行查询时按 db 的方言加引号，见 columnExpr
+func (s *Cnd) column(name string) (string, bool) {
+	if err := s.checkColumn(name); err != nil {
+		s.AddError(err)
+		return "", false
+	}
+	return name, true
+}
+
+func (s *Cnd) checkColumn(name string) error {
+	if err := ValidateIdentifier(name); err != nil {
+		return err
+	}
+	if s.AllowedCols == nil {
+		return nil
+	}
+	column := name
+	if idx := strings.LastIndex(strings.ToLower(column), " as "); idx > 0 {
+		column = strings.TrimSpace(column[:idx])
+	}
+	if column == "*" || strings.HasSuffix(column, ".*") || s.AllowedCols[column] {
+		return nil
+	}
+	if idx := strings.LastIndex(column, "."); idx >= 0 && s.AllowedCols[column[idx+1:]] {
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrColumnNotAllowed, name)
+}
+
+// withError 在新的查询实例上记录错误，避免污染传入的 db
+func withError(db *gorm.DB, err error) *gorm.DB {
+	tx := db.Session(&gorm.Session{})
+	_ = tx.AddError(err)
+	return tx
+}
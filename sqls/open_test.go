@@ -0,0 +1,70 @@
+package sqls_test
+
+import (
+	"testing"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// 测试通过配置打开 SQLite 数据库
+func TestOpen(t *testing.T) {
+	db, err := sqls.Open(sqls.DbConfig{
+		Url:                    "sqlite://:memory:",
+		MaxIdleConns:           1,
+		MaxOpenConns:           2,
+		ConnMaxLifetimeSeconds: 60,
+	}, sqls.WithLogger(logger.Discard), sqls.WithNamingStrategy(schema.NamingStrategy{TablePrefix: "t_"}))
+	assert.NoError(t, err)
+	assert.Same(t, db, sqls.DB())
+	assert.Equal(t, "sqlite", db.Dialector.Name())
+
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sqlDB.Stats().MaxOpenConnections)
+
+	assert.NoError(t, db.AutoMigrate(&CndUser{}))
+	assert.True(t, db.Migrator().HasTable("t_cnd_users"))
+}
+
+// 测试不支持的 Url
+func TestOpen_InvalidUrl(t *testing.T) {
+	_, err := sqls.Open(sqls.DbConfig{Url: "data.db"})
+	assert.Error(t, err)
+	_, err = sqls.Open(sqls.DbConfig{Url: "oracle://user@host/db"})
+	assert.Error(t, err)
+}
+
+// 测试选项与顺序无关，WithGormConfig 可以为 nil
+func TestOpen_OptionOrder(t *testing.T) {
+	config := &gorm.Config{SkipDefaultTransaction: true}
+	db, err := sqls.Open(sqls.DbConfig{Url: "sqlite://:memory:"},
+		sqls.WithNamingStrategy(schema.NamingStrategy{TablePrefix: "t_"}),
+		sqls.WithGormConfig(config),
+		sqls.WithLogger(logger.Discard))
+	assert.NoError(t, err)
+	assert.True(t, db.SkipDefaultTransaction)
+	assert.Equal(t, logger.Discard, db.Logger)
+	assert.Nil(t, config.Logger, "不修改传入的配置")
+	assert.Nil(t, config.NamingStrategy)
+	assert.NoError(t, db.AutoMigrate(&CndUser{}))
+	assert.True(t, db.Migrator().HasTable("t_cnd_users"))
+
+	db, err = sqls.Open(sqls.DbConfig{Url: "sqlite://:memory:"}, sqls.WithGormConfig(nil), sqls.WithLogger(logger.Discard))
+	assert.NoError(t, err)
+	assert.Equal(t, logger.Discard, db.Logger)
+}
+
+// 测试打开命名数据源，不替换默认数据库
+func TestOpen_WithName(t *testing.T) {
+	defaultDB, err := sqls.Open(sqls.DbConfig{Url: "sqlite://:memory:"})
+	assert.NoError(t, err)
+
+	db, err := sqls.Open(sqls.DbConfig{Url: "sqlite://:memory:"}, sqls.WithName("reporting"))
+	assert.NoError(t, err)
+	assert.Same(t, db, sqls.Use("reporting"))
+	assert.Same(t, defaultDB, sqls.DB())
+}
@@ -0,0 +1,300 @@
+package sqls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/YspCoder/simple/common/dates"
+	"github.com/YspCoder/simple/common/jsons"
+	"gorm.io/gorm"
+)
+
+// OutboxStatus 事件状态
+type OutboxStatus int
+
+const (
+	OutboxPending OutboxStatus = iota // 待投递
+	OutboxDone                        // 已投递
+	OutboxDead                        // 超过最大重试次数，不再投递
+)
+
+// OutboxEvent 发件箱事件，与业务数据在同一事务中写入，由 OutboxDispatcher 投递，
+// 使用前需要 AutoMigrate(&sqls.OutboxEvent{})
+type OutboxEvent struct {
+	GormModel
+	Topic      string       `gorm:"size:128;not null" json:"topic" form:"topic"`
+	Payload    string       `gorm:"type:text" json:"payload" form:"payload"`
+	Status     OutboxStatus `gorm:"not null;index:idx_outbox_status_next" json:"status" form:"status"`
+	Attempts   int          `gorm:"not null" json:"attempts" form:"attempts"`
+	NextTime   int64        `gorm:"not null;index:idx_outbox_status_next" json:"nextTime" form:"nextTime"` // 下次投递时间（毫秒时间戳）
+	LastError  string       `gorm:"type:text" json:"lastError" form:"lastError"`
+	CreateTime int64        `json:"createTime" form:"createTime"`
+	UpdateTime int64        `json:"updateTime" form:"updateTime"`
+}
+
+// Publish 在当前事务中写入发件箱事件，事务提交后由 OutboxDispatcher 投递，事务回滚时事件一起回滚。
+// payload 为 string、[]byte 时原样保存，其他类型序列化为 JSON
+func (t *TxContext) Publish(topic string, payload interface{}) error {
+	var data string
+	switch v := payload.(type) {
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		str, err := jsons.ToStr(payload)
+		if err != nil {
+			return err
+		}
+		data = str
+	}
+
+	now := dates.NowTimestamp()
+	return t.Tx.Create(&OutboxEvent{
+		Topic:      topic,
+		Payload:    data,
+		Status:     OutboxPending,
+		NextTime:   now,
+		CreateTime: now,
+		UpdateTime: now,
+	}).Error
+}
+
+// OutboxHandler 事件处理函数，返回错误时事件稍后重试。事件至少投递一次，处理函数需要保证幂等
+type OutboxHandler func(ctx context.Context, event *OutboxEvent) error
+
+var ErrNoOutboxHandler = errors.New("sqls: no outbox handler")
+
+type outboxOptions struct {
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	lease       time.Duration
+	backoff     func(attempts int) time.Duration
+}
+
+// OutboxOption 发件箱投递选项
+type OutboxOption func(o *outboxOptions)
+
+// WithOutboxInterval 设置轮询间隔，默认 1s
+func WithOutboxInterval(interval time.Duration) OutboxOption {
+	return func(o *outboxOptions) {
+		o.interval = interval
+	}
+}
+
+// WithOutboxBatchSize 设置每次轮询最多投递的事件数，默认 100
+func WithOutboxBatchSize(batchSize int) OutboxOption {
+	return func(o *outboxOptions) {
+		o.batchSize = batchSize
+	}
+}
+
+// WithOutboxMaxAttempts 设置最大投递次数，超过后事件状态置为 OutboxDead，默认 10
+func WithOutboxMaxAttempts(maxAttempts int) OutboxOption {
+	return func(o *outboxOptions) {
+		o.maxAttempts = maxAttempts
+	}
+}
+
+// WithOutboxLease 设置事件被领取后的租期，租期内其他投递器不会重复领取，默认 1min
+func WithOutboxLease(lease time.Duration) OutboxOption {
+	return func(o *outboxOptions) {
+		o.lease = lease
+	}
+}
+
+// WithOutboxBackoff 设置第 attempts 次投递失败后的重试间隔，默认从 1s 开始翻倍，最多 10min
+func WithOutboxBackoff(backoff func(attempts int) time.Duration) OutboxOption {
+	return func(o *outboxOptions) {
+		o.backoff = backoff
+	}
+}
+
+func defaultOutboxBackoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempts && d < 10*time.Minute; i++ {
+		d *= 2
+	}
+	return min(d, 10*time.Minute)
+}
+
+// OutboxDispatcher 发件箱投递器，轮询发件箱表并将事件投递给进程内注册的处理函数，
+// 多个进程可以同时运行投递器，每个投递器只领取已注册处理函数的 topic 的事件，事件通过租期领取避免重复投递
+type OutboxDispatcher struct {
+	db       *gorm.DB
+	options  outboxOptions
+	mu       sync.RWMutex
+	handlers map[string][]OutboxHandler
+}
+
+// NewOutboxDispatcher 创建投递器，db 为 nil 时使用 DB()
+func NewOutboxDispatcher(db *gorm.DB, opts ...OutboxOption) *OutboxDispatcher {
+	options := outboxOptions{
+		interval:    time.Second,
+		batchSize:   100,
+		maxAttempts: 10,
+		lease:       time.Minute,
+		backoff:     defaultOutboxBackoff,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &OutboxDispatcher{
+		db:       db,
+		options:  options,
+		handlers: map[string][]OutboxHandler{},
+	}
+}
+
+// Handle 注册 topic 的处理函数，同一 topic 可以注册多个，全部成功后事件才算投递成功
+func (d *OutboxDispatcher) Handle(topic string, handler OutboxHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[topic] = append(d.handlers[topic], handler)
+}
+
+// Start 在新的协程中运行投递器，ctx 取消后停止
+func (d *OutboxDispatcher) Start(ctx context.Context) {
+	go d.Run(ctx)
+}
+
+// Run 运行投递器直到 ctx 取消
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.options.interval)
+	defer ticker.Stop()
+	for {
+		n, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error(err.Error(), slog.Any("error", err))
+		}
+		// 本批次已满说明可能还有待投递的事件，立即继续
+		if n >= d.options.batchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce 领取一批到期的事件并投递，只领取已注册处理函数的 topic，返回领取到的事件数
+func (d *OutboxDispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	topics := d.topics()
+	if len(topics) == 0 {
+		return 0, nil
+	}
+	db := d.getDB().WithContext(ctx)
+	now := dates.NowTimestamp()
+
+	var events []OutboxEvent
+	if err := ForcePrimary(db).Where("status = ? AND next_time <= ? AND topic IN ?", OutboxPending, now, topics).
+		Order("id ASC").Limit(d.options.batchSize).Find(&events).Error; err != nil {
+		return 0, err
+	}
+
+	claimed := 0
+	for i := range events {
+		event := &events[i]
+		ok, err := d.claim(db, event, topics, now)
+		if err != nil {
+			return claimed, err
+		}
+		if !ok {
+			continue
+		}
+		claimed++
+		if err := d.complete(db, event, d.deliver(ctx, event)); err != nil {
+			return claimed, err
+		}
+	}
+	return claimed, nil
+}
+
+// claim 通过延后 next_time 领取事件，返回 false 表示事件已被其他投递器领取
+func (d *OutboxDispatcher) claim(db *gorm.DB, event *OutboxEvent, topics []string, now int64) (bool, error) {
+	leaseTime := now + d.options.lease.Milliseconds()
+	ret := db.Model(&OutboxEvent{}).
+		Where("id = ? AND status = ? AND next_time = ? AND topic IN ?", event.Id, OutboxPending, event.NextTime, topics).
+		Updates(map[string]interface{}{"next_time": leaseTime, "update_time": now})
+	if ret.Error != nil {
+		return false, ret.Error
+	}
+	event.NextTime = leaseTime
+	return ret.RowsAffected == 1, nil
+}
+
+// topics 已注册处理函数的 topic
+func (d *OutboxDispatcher) topics() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	topics := make([]string, 0, len(d.handlers))
+	for topic := range d.handlers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+func (d *OutboxDispatcher) deliver(ctx context.Context, event *OutboxEvent) error {
+	d.mu.RLock()
+	handlers := d.handlers[event.Topic]
+	d.mu.RUnlock()
+	if len(handlers) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoOutboxHandler, event.Topic)
+	}
+
+	for _, handler := range handlers {
+		if err := safeCall(func() error { return handler(ctx, event) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// complete 记录投递结果：成功置为 OutboxDone，失败时按退避时间重试，超过最大次数置为 OutboxDead。
+// 只在租约仍属于当前投递器时更新，租约过期后事件已被其他投递器重新领取的，不覆盖其结果
+func (d *OutboxDispatcher) complete(db *gorm.DB, event *OutboxEvent, deliverErr error) error {
+	leaseTime := event.NextTime
+	now := dates.NowTimestamp()
+	event.Attempts++
+	values := map[string]interface{}{"attempts": event.Attempts, "update_time": now}
+	if deliverErr == nil {
+		event.Status = OutboxDone
+		values["last_error"] = ""
+	} else {
+		if event.Attempts >= d.options.maxAttempts {
+			event.Status = OutboxDead
+		} else {
+			values["next_time"] = now + d.options.backoff(event.Attempts).Milliseconds()
+		}
+		values["last_error"] = deliverErr.Error()
+		slog.Warn("outbox event delivery failed", slog.Int64("id", event.Id),
+			slog.String("topic", event.Topic), slog.Int("attempts", event.Attempts), slog.Any("error", deliverErr))
+	}
+	values["status"] = event.Status
+	// 使用新的 context，避免 ctx 取消后已投递的事件无法标记完成
+	ret := db.WithContext(context.WithoutCancel(db.Statement.Context)).Model(&OutboxEvent{}).
+		Where("id = ? AND status = ? AND next_time = ?", event.Id, OutboxPending, leaseTime).Updates(values)
+	if ret.Error != nil {
+		return ret.Error
+	}
+	if ret.RowsAffected == 0 {
+		slog.Warn("outbox event lease lost", slog.Int64("id", event.Id), slog.String("topic", event.Topic))
+	}
+	return nil
+}
+
+func (d *OutboxDispatcher) getDB() *gorm.DB {
+	if d.db != nil {
+		return d.db
+	}
+	return DB()
+}
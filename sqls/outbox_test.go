@@ -0,0 +1,172 @@
+package sqls_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupOutboxTestDB(t *testing.T) {
+	db := setupCndTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&sqls.OutboxEvent{}))
+}
+
+func findOutboxEvents(t *testing.T) []sqls.OutboxEvent {
+	var events []sqls.OutboxEvent
+	assert.NoError(t, sqls.DB().Order("id ASC").Find(&events).Error)
+	return events
+}
+
+// 测试事件与业务数据在同一事务中写入，事务回滚时事件一起回滚
+func TestTxContext_Publish(t *testing.T) {
+	setupOutboxTestDB(t)
+
+	err := sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		order := &CndOrder{UserId: 1, Amount: 99, Status: "paid"}
+		if err := ctx.Tx.Create(order).Error; err != nil {
+			return err
+		}
+		return ctx.Publish("order.paid", map[string]interface{}{"orderId": order.ID})
+	})
+	assert.NoError(t, err)
+
+	_ = sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		if err := ctx.Publish("order.paid", "rollback"); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+
+	events := findOutboxEvents(t)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "order.paid", events[0].Topic)
+	assert.Equal(t, `{"orderId":5}`, events[0].Payload)
+	assert.Equal(t, sqls.OutboxPending, events[0].Status)
+}
+
+// 测试投递成功、失败重试及超过最大次数后进入死信状态
+func TestOutboxDispatcher_DispatchOnce(t *testing.T) {
+	setupOutboxTestDB(t)
+	assert.NoError(t, sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		if err := ctx.Publish("user.created", "tom"); err != nil {
+			return err
+		}
+		return ctx.Publish("user.deleted", "jerry")
+	}))
+
+	dispatcher := sqls.NewOutboxDispatcher(nil,
+		sqls.WithOutboxMaxAttempts(2),
+		sqls.WithOutboxBackoff(func(attempts int) time.Duration { return 0 }))
+	var received []string
+	dispatcher.Handle("user.created", func(ctx context.Context, event *sqls.OutboxEvent) error {
+		received = append(received, event.Payload)
+		return nil
+	})
+	dispatcher.Handle("user.deleted", func(ctx context.Context, event *sqls.OutboxEvent) error {
+		panic("handler panic")
+	})
+
+	n, err := dispatcher.DispatchOnce(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"tom"}, received)
+
+	events := findOutboxEvents(t)
+	assert.Equal(t, sqls.OutboxDone, events[0].Status)
+	assert.Equal(t, sqls.OutboxPending, events[1].Status)
+	assert.Equal(t, 1, events[1].Attempts)
+	assert.Contains(t, events[1].LastError, "handler panic")
+
+	n, err = dispatcher.DispatchOnce(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	events = findOutboxEvents(t)
+	assert.Equal(t, sqls.OutboxDead, events[1].Status)
+	assert.Equal(t, 2, events[1].Attempts)
+
+	n, err = dispatcher.DispatchOnce(t.Context())
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, []string{"tom"}, received)
+}
+
+// 测试投递耗时超过租约、事件被其他投递器重新领取后，不覆盖其投递结果
+func TestOutboxDispatcher_LeaseLost(t *testing.T) {
+	setupOutboxTestDB(t)
+	assert.NoError(t, sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		return ctx.Publish("user.created", "tom")
+	}))
+
+	dispatcher := sqls.NewOutboxDispatcher(nil)
+	dispatcher.Handle("user.created", func(ctx context.Context, event *sqls.OutboxEvent) error {
+		// 模拟租约过期后其他投递器重新领取事件
+		return sqls.DB().Model(&sqls.OutboxEvent{}).Where("id = ?", event.Id).
+			Update("next_time", event.NextTime+1).Error
+	})
+
+	n, err := dispatcher.DispatchOnce(t.Context())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	events := findOutboxEvents(t)
+	assert.Equal(t, sqls.OutboxPending, events[0].Status)
+	assert.Zero(t, events[0].Attempts)
+}
+
+// 测试投递器只领取已注册处理函数的 topic，不会消耗其他进程的事件的投递次数
+func TestOutboxDispatcher_Topics(t *testing.T) {
+	setupOutboxTestDB(t)
+	assert.NoError(t, sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		if err := ctx.Publish("order.paid", "1"); err != nil {
+			return err
+		}
+		return ctx.Publish("mail.send", "2")
+	}))
+
+	n, err := sqls.NewOutboxDispatcher(nil).DispatchOnce(t.Context())
+	assert.NoError(t, err)
+	assert.Zero(t, n, "没有注册处理函数时不领取事件")
+
+	mailer := sqls.NewOutboxDispatcher(nil, sqls.WithOutboxMaxAttempts(1))
+	mailer.Handle("mail.send", func(ctx context.Context, event *sqls.OutboxEvent) error {
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		_, err = mailer.DispatchOnce(t.Context())
+		assert.NoError(t, err)
+	}
+
+	events := findOutboxEvents(t)
+	assert.Equal(t, sqls.OutboxPending, events[0].Status)
+	assert.Zero(t, events[0].Attempts)
+	assert.Equal(t, sqls.OutboxDone, events[1].Status)
+}
+
+// 测试后台运行投递器
+func TestOutboxDispatcher_Start(t *testing.T) {
+	setupOutboxTestDB(t)
+	received := make(chan string, 1)
+	dispatcher := sqls.NewOutboxDispatcher(sqls.DB(), sqls.WithOutboxInterval(10*time.Millisecond))
+	dispatcher.Handle("ping", func(ctx context.Context, event *sqls.OutboxEvent) error {
+		received <- event.Payload
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	dispatcher.Start(ctx)
+
+	assert.NoError(t, sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		return ctx.Publish("ping", []byte("pong"))
+	}))
+	select {
+	case payload := <-received:
+		assert.Equal(t, "pong", payload)
+	case <-time.After(2 * time.Second):
+		t.Fatal("事件未被投递")
+	}
+}
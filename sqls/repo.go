@@ -0,0 +1,143 @@
+package sqls
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repo 基于 Cnd 的通用数据访问，T 为模型类型，例如：sqls.NewRepo[User]()。
+// 默认使用 DB()，在事务中使用时通过 WithTx 传入 TxContext。
+type Repo[T any] struct {
+	db *gorm.DB
+}
+
+func NewRepo[T any]() *Repo[T] {
+	return &Repo[T]{}
+}
+
+// With 返回使用指定数据库连接的 Repo
+func (r *Repo[T]) With(db *gorm.DB) *Repo[T] {
+	return &Repo[T]{db: db}
+}
+
+// WithTx 返回使用事务连接的 Repo
+func (r *Repo[T]) WithTx(ctx *TxContext) *Repo[T] {
+	return r.With(ctx.Tx)
+}
+
+// DB 当前使用的数据库连接
+func (r *Repo[T]) DB() *gorm.DB {
+	if r.db != nil {
+		return r.db
+	}
+	return DB()
+}
+
+// Get 根据主键查询，数据不存在时返回 nil, nil
+func (r *Repo[T]) Get(id interface{}) (*T, error) {
+	ret := new(T)
+	if err := r.DB().Where(primaryKeyEq(id)).Take(ret).Error; err != nil {
+		return nil, ignoreNotFound(err)
+	}
+	return ret, nil
+}
+
+// Take 查询符合条件的一条数据，数据不存在时返回 nil, nil
+func (r *Repo[T]) Take(cnd *Cnd) (*T, error) {
+	cnd = orNewCnd(cnd)
+	tx, cancel := cnd.withContext(r.DB().Statement.Context, r.DB())
+	defer cancel()
+
+	ret := new(T)
+	if err := cnd.Build(tx).Take(ret).Error; err != nil {
+		return nil, ignoreNotFound(err)
+	}
+	return ret, nil
+}
+
+// Find 查询符合条件的数据
+func (r *Repo[T]) Find(cnd *Cnd) ([]T, error) {
+	var list []T
+	if err := orNewCnd(cnd).FindCtx(r.DB().Statement.Context, r.DB(), &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FindPage 分页查询，返回的 Paging 中包含总数据条数
+func (r *Repo[T]) FindPage(cnd *Cnd) ([]T, *Paging, error) {
+	cnd = orNewCnd(cnd)
+	list, err := r.Find(cnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := r.Count(cnd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	paging := &Paging{Total: total}
+	if cnd.Paging != nil {
+		paging.Page = cnd.Paging.Page
+		paging.Limit = cnd.Paging.Limit
+	}
+	return list, paging, nil
+}
+
+// Count 查询符合条件的数据条数
+func (r *Repo[T]) Count(cnd *Cnd) (int64, error) {
+	return orNewCnd(cnd).CountCtx(r.DB().Statement.Context, r.DB(), new(T))
+}
+
+// Exists 是否存在符合条件的数据
+func (r *Repo[T]) Exists(cnd *Cnd) (bool, error) {
+	cnd = orNewCnd(cnd)
+	tx, cancel := cnd.withContext(r.DB().Statement.Context, r.DB())
+	defer cancel()
+
+	var one int
+	tx = cnd.buildCount(tx.Model(new(T))).Select("1").Limit(1).Scan(&one)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+func (r *Repo[T]) Create(t *T) error {
+	return r.DB().Create(t).Error
+}
+
+// Updates 根据主键更新多个字段，values 可以是 map 或结构体（结构体零值字段不会更新）
+func (r *Repo[T]) Updates(id interface{}, values interface{}) error {
+	return r.DB().Model(new(T)).Where(primaryKeyEq(id)).Updates(values).Error
+}
+
+// UpdateColumn 根据主键更新单个字段
+func (r *Repo[T]) UpdateColumn(id interface{}, column string, value interface{}) error {
+	return r.DB().Model(new(T)).Where(primaryKeyEq(id)).UpdateColumn(column, value).Error
+}
+
+// Delete 根据主键删除
+func (r *Repo[T]) Delete(id interface{}) error {
+	return r.DB().Where(primaryKeyEq(id)).Delete(new(T)).Error
+}
+
+func primaryKeyEq(id interface{}) clause.Expression {
+	return clause.Eq{Column: clause.PrimaryColumn, Value: id}
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+func orNewCnd(cnd *Cnd) *Cnd {
+	if cnd == nil {
+		return NewCnd()
+	}
+	return cnd
+}
@@ -0,0 +1,127 @@
+package sqls_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// 测试 Repo 的增删改查
+func TestRepo_CRUD(t *testing.T) {
+	setupCndTestDB(t)
+	repo := sqls.NewRepo[CndUser]()
+
+	user := &CndUser{Name: "lucy", Age: 22}
+	assert.NoError(t, repo.Create(user))
+	assert.NotZero(t, user.ID)
+
+	got, err := repo.Get(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "lucy", got.Name)
+
+	assert.NoError(t, repo.Updates(user.ID, map[string]interface{}{"age": 23, "status": 1}))
+	assert.NoError(t, repo.UpdateColumn(user.ID, "phone", "13700000000"))
+	got, _ = repo.Get(user.ID)
+	assert.Equal(t, 23, got.Age)
+	assert.Equal(t, 1, got.Status)
+	assert.Equal(t, "13700000000", got.Phone)
+
+	assert.NoError(t, repo.Delete(user.ID))
+	got, err = repo.Get(user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+// 测试 Repo 的条件查询
+func TestRepo_Query(t *testing.T) {
+	setupCndTestDB(t)
+	repo := sqls.NewRepo[CndUser]()
+
+	got, err := repo.Take(sqls.NewCnd().Eq("name", "alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, 25, got.Age)
+
+	got, err = repo.Take(sqls.NewCnd().Eq("name", "nobody"))
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	list, err := repo.Find(sqls.NewCnd().Eq("status", 1).Asc("id"))
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "jerry", list[0].Name)
+
+	list, paging, err := repo.FindPage(sqls.NewCnd().Gte("age", 20).Asc("id").Page(2, 2))
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "bob", list[0].Name)
+	assert.Equal(t, int64(3), paging.Total)
+	assert.Equal(t, 2, paging.TotalPage())
+
+	exists, err := repo.Exists(sqls.NewCnd().Eq("name", "tom"))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = repo.Exists(sqls.NewCnd().Eq("name", "nobody"))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+// 测试 Repo 在事务中使用
+func TestRepo_WithTx(t *testing.T) {
+	setupCndTestDB(t)
+	repo := sqls.NewRepo[CndUser]()
+
+	err := sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		if err := repo.WithTx(ctx).Create(&CndUser{Name: "rollback"}); err != nil {
+			return err
+		}
+		return errors.New("rollback")
+	})
+	assert.Error(t, err)
+
+	count, err := repo.Count(sqls.NewCnd().Eq("name", "rollback"))
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+type VersionedDoc struct {
+	sqls.GormModel
+	sqls.Versioned
+	Title string
+}
+
+// 测试乐观锁更新
+func TestRepo_UpdatesVersioned(t *testing.T) {
+	db := setupCndTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&VersionedDoc{}))
+	repo := sqls.NewRepo[VersionedDoc]()
+
+	doc := &VersionedDoc{Title: "draft"}
+	assert.NoError(t, repo.Create(doc))
+	assert.Zero(t, doc.Version)
+
+	// 两个请求读取到同一版本，后提交的更新失败
+	assert.NoError(t, repo.UpdatesVersioned(doc.Id, doc.Version, map[string]interface{}{"title": "first"}))
+	err := repo.UpdatesVersioned(doc.Id, doc.Version, map[string]interface{}{"title": "second"})
+	assert.ErrorIs(t, err, sqls.ErrStaleObject)
+
+	got, _ := repo.Get(doc.Id)
+	assert.Equal(t, "first", got.Title)
+	assert.Equal(t, int64(1), got.Version)
+
+	err = sqls.NewCnd().Eq("title", "first").UpdatesVersioned(sqls.DB(), &VersionedDoc{}, got.Version, map[string]interface{}{"title": "third"})
+	assert.NoError(t, err)
+	got, _ = repo.Get(doc.Id)
+	assert.Equal(t, "third", got.Title)
+	assert.Equal(t, int64(2), got.Version)
+
+	// 未设置条件时拒绝更新
+	err = sqls.NewCnd().UpdatesVersioned(sqls.DB(), &VersionedDoc{}, got.Version, map[string]interface{}{"title": "all"})
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+	err = sqls.NewCnd().AllowGlobal().UpdatesVersioned(sqls.DB(), &VersionedDoc{}, got.Version, map[string]interface{}{"title": "all"})
+	assert.NoError(t, err)
+	got, _ = repo.Get(doc.Id)
+	assert.Equal(t, "all", got.Title)
+}
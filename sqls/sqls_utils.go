@@ -14,27 +14,27 @@ func SqlNullString(value string) sql.NullString {
 	}
 }
 
+// KeywordWrap 使用 DB() 对应的方言为标识符加引号，支持 table.column 及 column AS alias 形式，标识符中的引号会被转义。
+// 列名来自请求参数等不可信来源时，应使用 Cnd 的条件方法，它们会先通过 ValidateIdentifier 校验，
+// 并在执行查询时使用传入的 db 对应的方言加引号
 func KeywordWrap(keyword string) string {
-	if strs.IsBlank(keyword) {
+	return wrapKeyword(CurrentDialect(), keyword)
+}
+
+// wrapKeyword 使用方言 dialect 为标识符加引号，见 KeywordWrap
+func wrapKeyword(dialect Dialect, keyword string) string {
+	if strs.IsBlank(keyword) || keyword == "*" {
 		return keyword
 	}
+	// 带别名的字段，例如：u.name AS user_name，字段和别名分别处理
+	if idx := strings.LastIndex(strings.ToLower(keyword), " as "); idx > 0 {
+		return wrapKeyword(dialect, strings.TrimSpace(keyword[:idx])) + " AS " + wrapKeyword(dialect, strings.TrimSpace(keyword[idx+4:]))
+	}
 	// If already quoted, return as-is
-	if (strings.HasPrefix(keyword, "`") && strings.HasSuffix(keyword, "`")) ||
-		(strings.HasPrefix(keyword, "\"") && strings.HasSuffix(keyword, "\"")) {
+	if isQuoted(keyword) {
 		return keyword
 	}
 
-	// Detect current DB dialect via GORM and choose quote style
-	dialect := ""
-	if db := DB(); db != nil && db.Dialector != nil {
-		dialect = db.Dialector.Name()
-	}
-
-	quote := "`" // default to MySQL-style backticks to keep prior behavior
-	if strings.Contains(strings.ToLower(dialect), "postgre") {
-		quote = "\""
-	}
-
 	// If identifier contains dot, quote each part separately (e.g., schema.table or table.column)
 	if strings.Contains(keyword, ".") {
 		parts := strings.Split(keyword, ".")
@@ -44,15 +44,30 @@ func KeywordWrap(keyword string) string {
 				continue
 			}
 			// avoid double quoting if a part is already quoted
-			if (strings.HasPrefix(p, quote) && strings.HasSuffix(p, quote)) ||
-				(strings.HasPrefix(p, "`") && strings.HasSuffix(p, "`")) ||
-				(strings.HasPrefix(p, "\"") && strings.HasSuffix(p, "\"")) {
+			if isQuoted(p) {
 				continue
 			}
-			parts[i] = quote + p + quote
+			parts[i] = dialect.Quote(p)
 		}
 		return strings.Join(parts, ".")
 	}
 
-	return quote + keyword + quote
+	return dialect.Quote(keyword)
+}
+
+// isQuoted 是否为已加引号的标识符，引号内不能包含未转义的引号
+func isQuoted(keyword string) bool {
+	if len(keyword) < 2 {
+		return false
+	}
+	inner := keyword[1 : len(keyword)-1]
+	switch {
+	case keyword[0] == '`' && keyword[len(keyword)-1] == '`':
+		return !strings.Contains(strings.ReplaceAll(inner, "``", ""), "`")
+	case keyword[0] == '"' && keyword[len(keyword)-1] == '"':
+		return !strings.Contains(strings.ReplaceAll(inner, `""`, ""), `"`)
+	case keyword[0] == '[' && keyword[len(keyword)-1] == ']':
+		return !strings.Contains(strings.ReplaceAll(inner, "]]", ""), "]")
+	}
+	return false
 }
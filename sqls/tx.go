@@ -1,6 +1,8 @@
 package sqls
 
 import (
+	"context"
+
 	"gorm.io/gorm"
 )
 
@@ -12,33 +14,187 @@ type RegisterCallbackFunc func(CallbackFunc)
 
 type TxFunc func(ctx *TxContext) error
 
+// TxCtxFunc 带上下文的事务函数，ctx 中携带当前事务，嵌套调用 WithTransactionCtx 时传入 ctx
+type TxCtxFunc func(ctx context.Context, tx *TxContext) error
+
 type TxContext struct {
 	Tx               *gorm.DB
-	RegisterCallback RegisterCallbackFunc
+	RegisterCallback RegisterCallbackFunc // 注册事务提交后执行的回调，见 AfterCommit
+
+	db            *gorm.DB          // 开启事务的数据库
+	beforeCommit  []func() error    // 事务提交前执行的钩子
+	afterCommit   []func() error    // 事务提交后执行的回调
+	afterRollback []func(err error) // 事务回滚后执行的钩子
+}
+
+// Propagation 事务传播方式，决定已存在事务时 WithTransactionCtx 的行为
+type Propagation int
+
+const (
+	PropagationRequired    Propagation = iota // 存在事务时加入该事务，否则新建事务（默认）
+	PropagationRequiresNew                    // 总是新建独立的事务
+	PropagationNested                         // 存在事务时创建保存点，失败只回滚到保存点，否则新建事务
+)
+
+type txOptions struct {
+	propagation     Propagation
+	db              *gorm.DB
+	retry           *RetryPolicy
+	pool            *CallbackPool
+	onCallbackError func(err *TxCallbackError)
+}
+
+// TxOption 事务选项
+type TxOption func(o *txOptions)
+
+// WithPropagation 设置事务传播方式
+func WithPropagation(propagation Propagation) TxOption {
+	return func(o *txOptions) {
+		o.propagation = propagation
+	}
 }
 
-func WithTransaction(fn TxFunc) error {
-	var callbacks []CallbackFunc
+// WithTxDB 设置新建事务使用的数据库，默认使用外层事务的数据库或 DB()
+func WithTxDB(db *gorm.DB) TxOption {
+	return func(o *txOptions) {
+		o.db = db
+	}
+}
+
+type txContextKey struct{}
+
+// TxFromContext 获取 ctx 中的事务
+func TxFromContext(ctx context.Context) (*TxContext, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txContextKey{}).(*TxContext)
+	return tx, ok
+}
 
-	registerCallback := func(fn CallbackFunc) {
-		callbacks = append(callbacks, fn)
+// DBFrom 获取 ctx 对应的数据库连接：存在事务时返回事务连接，否则返回 DB()
+func DBFrom(ctx context.Context) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.Tx
 	}
+	if ctx == nil {
+		return DB()
+	}
+	return DB().WithContext(ctx)
+}
+
+func WithTransaction(fn TxFunc, opts ...TxOption) error {
+	return WithTransactionOn(DB(), fn, opts...)
+}
+
+// WithTransactionOn 在指定的数据库上执行事务，例如：WithTransactionOn(sqls.Use("orders"), fn)
+func WithTransactionOn(db *gorm.DB, fn TxFunc, opts ...TxOption) error {
+	return WithTransactionCtx(context.Background(), func(ctx context.Context, tx *TxContext) error {
+		return fn(tx)
+	}, append([]TxOption{WithTxDB(db)}, opts...)...)
+}
 
-	err := DB().Transaction(func(tx *gorm.DB) error {
-		ctx := &TxContext{
-			Tx:               tx,
-			RegisterCallback: registerCallback,
+// WithTransactionCtx 执行事务，并将事务保存在传给 fn 的 ctx 中。
+// ctx 中已存在同一数据库的事务时按传播方式处理：加入外层事务（回调在外层事务提交后执行）、新建独立事务或创建保存点；
+// WithTxDB 指定了其他数据库时总是在该数据库上新建事务。
+// 重试策略（WithRetry）只对新建的事务生效
+func WithTransactionCtx(ctx context.Context, fn TxCtxFunc, opts ...TxOption) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	options := &txOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	outer, ok := TxFromContext(ctx)
+	if ok && (options.db == nil || sameDB(options.db, outer.db)) {
+		switch options.propagation {
+		case PropagationRequired:
+			return fn(ctx, outer)
+		case PropagationNested:
+			return outer.nested(ctx, fn)
 		}
-		return fn(ctx)
-	})
+	}
 
-	if err == nil {
-		for _, callback := range callbacks {
-			if callback != nil {
-				callback()
-			}
+	db := options.db
+	if db == nil {
+		if ok {
+			db = outer.db
+		} else {
+			db = DB()
 		}
 	}
 
-	return err
+	var (
+		txCtx *TxContext
+		err   error
+	)
+	if options.retry != nil {
+		txCtx, err = runTransactionWithRetry(ctx, db, fn, options)
+	} else {
+		txCtx, err = runTransaction(ctx, db, fn)
+	}
+	if err != nil {
+		return err
+	}
+	// 回调只在最终提交成功后执行，其错误不作为事务的错误返回，避免被当作回滚或触发重试
+	txCtx.runCallbacks(options)
+	return nil
+}
+
+// sameDB 是否为同一个数据库，同一数据库的不同会话共用连接池
+func sameDB(a, b *gorm.DB) bool {
+	return a.Config.ConnPool == b.Config.ConnPool
+}
+
+// runTransaction 执行事务：fn 成功后执行 BeforeCommit 钩子并提交，失败时执行 AfterRollback 钩子。
+// AfterCommit 回调由调用方在提交成功后执行
+func runTransaction(ctx context.Context, db *gorm.DB, fn TxCtxFunc) (*TxContext, error) {
+	txCtx := newTxContext(db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txCtx.Tx = tx
+		if err := fn(context.WithValue(ctx, txContextKey{}, txCtx), txCtx); err != nil {
+			return err
+		}
+		return txCtx.runBeforeCommit()
+	})
+
+	if err != nil {
+		txCtx.runAfterRollback(err)
+		return nil, err
+	}
+	return txCtx, nil
+}
+
+// nested 在当前事务中创建保存点执行 fn，成功后钩子及回调合并到当前事务，
+// 失败时回滚到保存点，执行 AfterRollback 钩子并丢弃其他钩子及回调
+func (t *TxContext) nested(ctx context.Context, fn TxCtxFunc) error {
+	inner := newTxContext(t.db)
+	err := t.Tx.Transaction(func(tx *gorm.DB) error {
+		inner.Tx = tx
+		return fn(context.WithValue(ctx, txContextKey{}, inner), inner)
+	})
+	if err != nil {
+		inner.runAfterRollback(err)
+		return err
+	}
+	t.beforeCommit = append(t.beforeCommit, inner.beforeCommit...)
+	t.afterCommit = append(t.afterCommit, inner.afterCommit...)
+	t.afterRollback = append(t.afterRollback, inner.afterRollback...)
+	return nil
+}
+
+func newTxContext(db *gorm.DB) *TxContext {
+	txCtx := &TxContext{db: db}
+	txCtx.RegisterCallback = func(fn CallbackFunc) {
+		if fn == nil {
+			return
+		}
+		txCtx.AfterCommit(func() error {
+			fn()
+			return nil
+		})
+	}
+	return txCtx
 }
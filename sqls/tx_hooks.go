@@ -0,0 +1,173 @@
+package sqls
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// TxCallbackError 事务已提交，但部分 AfterCommit 回调执行失败（返回错误或 panic）
+type TxCallbackError struct {
+	Errors []error
+}
+
+func (e *TxCallbackError) Error() string {
+	return "sqls: transaction committed but after-commit callbacks failed: " + errors.Join(e.Errors...).Error()
+}
+
+func (e *TxCallbackError) Unwrap() []error {
+	return e.Errors
+}
+
+// BeforeCommit 注册事务提交前执行的钩子，按注册顺序执行，返回错误时事务回滚。
+// 加入外层事务或使用保存点时，钩子在最外层事务提交前执行
+func (t *TxContext) BeforeCommit(fn func() error) {
+	if fn != nil {
+		t.beforeCommit = append(t.beforeCommit, fn)
+	}
+}
+
+// AfterCommit 注册事务提交后执行的回调，按注册顺序执行。
+// 回调返回的错误及 panic 会被收集，全部执行完后以 *TxCallbackError 交给 WithCallbackErrorHandler 设置的处理函数，
+// 默认记录错误日志；事务本身返回 nil，不影响已提交的事务
+func (t *TxContext) AfterCommit(fn func() error) {
+	if fn != nil {
+		t.afterCommit = append(t.afterCommit, fn)
+	}
+}
+
+// AfterRollback 注册事务回滚后执行的钩子，err 为导致回滚的错误。
+// 使用保存点时，回滚到保存点也会执行保存点内注册的钩子
+func (t *TxContext) AfterRollback(fn func(err error)) {
+	if fn != nil {
+		t.afterRollback = append(t.afterRollback, fn)
+	}
+}
+
+func (t *TxContext) runBeforeCommit() error {
+	// 钩子中可能继续注册钩子，按下标遍历
+	for i := 0; i < len(t.beforeCommit); i++ {
+		if err := t.beforeCommit[i](); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TxContext) runAfterCommit() *TxCallbackError {
+	var errs []error
+	for _, fn := range t.afterCommit {
+		if err := safeCall(fn); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &TxCallbackError{Errors: errs}
+	}
+	return nil
+}
+
+func (t *TxContext) runAfterRollback(cause error) {
+	for _, fn := range t.afterRollback {
+		if err := safeCall(func() error {
+			fn(cause)
+			return nil
+		}); err != nil {
+			slog.Error(err.Error(), slog.Any("error", err))
+		}
+	}
+}
+
+func safeCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("sqls: transaction callback panic: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// runCallbacks 事务提交后执行 AfterCommit 回调，设置了协程池时异步执行
+func (t *TxContext) runCallbacks(options *txOptions) {
+	run := func() {
+		if err := t.runAfterCommit(); err != nil {
+			if options.onCallbackError != nil {
+				options.onCallbackError(err)
+			} else {
+				slog.Error(err.Error(), slog.Any("error", err))
+			}
+		}
+	}
+	if options.pool != nil {
+		options.pool.submit(run)
+		return
+	}
+	run()
+}
+
+// CallbackPool 异步执行 AfterCommit 回调的协程池，同一事务的回调在同一任务中按顺序执行
+type CallbackPool struct {
+	tasks  chan func()
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewCallbackPool 创建协程池，workers 为并发执行的协程数，queueSize 为等待队列长度，队列满时提交任务会阻塞
+func NewCallbackPool(workers, queueSize int) *CallbackPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	p := &CallbackPool{tasks: make(chan func(), queueSize)}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for task := range p.tasks {
+				task()
+			}
+		}()
+	}
+	return p
+}
+
+func (p *CallbackPool) submit(task func()) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		// 协程池已关闭时同步执行
+		task()
+		return
+	}
+	p.tasks <- task
+}
+
+// Close 关闭协程池并等待已提交的任务执行完成，关闭后提交的任务同步执行
+func (p *CallbackPool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// WithAsyncCallbacks 在协程池中异步执行 AfterCommit 回调，回调的错误见 WithCallbackErrorHandler
+func WithAsyncCallbacks(pool *CallbackPool) TxOption {
+	return func(o *txOptions) {
+		o.pool = pool
+	}
+}
+
+// WithCallbackErrorHandler 设置 AfterCommit 回调执行失败时的处理函数，默认记录错误日志。
+// 处理函数在回调执行完后调用，异步执行回调时在协程池中调用
+func WithCallbackErrorHandler(fn func(err *TxCallbackError)) TxOption {
+	return func(o *txOptions) {
+		o.onCallbackError = fn
+	}
+}
@@ -0,0 +1,104 @@
+package sqls
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// isSQLiteBusy 判断是否为 github.com/mattn/go-sqlite3 的 BUSY 错误，该驱动依赖 cgo，见 tx_retry_cgo.go
+var isSQLiteBusy = func(err error) (busy, ok bool) {
+	return false, false
+}
+
+// RetryPolicy 事务重试策略，事务因死锁、序列化失败等原因失败时整体重新执行
+type RetryPolicy struct {
+	MaxAttempts    int                  // 最大执行次数（包含第一次），小于等于 1 时不重试
+	InitialBackoff time.Duration        // 第一次重试前的等待时间，之后每次翻倍
+	MaxBackoff     time.Duration        // 最大等待时间，0 表示不限制
+	Retryable      func(err error) bool // 判断错误是否可重试，默认 IsRetryableError
+}
+
+// DefaultRetryPolicy 默认重试策略：最多执行 3 次，等待时间从 20ms 开始翻倍，最多 1s
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 20 * time.Millisecond,
+	MaxBackoff:     time.Second,
+}
+
+// WithRetry 设置事务重试策略，fn 可能被执行多次，需要保证 fn 中除数据库以外的操作可重复执行，
+// 通过 RegisterCallback 注册的回调只在最终成功的那次提交后执行一次
+func WithRetry(policy RetryPolicy) TxOption {
+	return func(o *txOptions) {
+		o.retry = &policy
+	}
+}
+
+// IsRetryableError 判断错误是否为可重试的事务错误：
+// MySQL 死锁（1213）、Postgres 序列化失败（40001）与死锁（40P01）、SQLite BUSY
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1213
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+	if busy, ok := isSQLiteBusy(err); ok {
+		return busy
+	}
+	// 无法识别驱动错误类型时（例如错误被转换为字符串、未启用 cgo、纯 Go 实现的 SQLite 驱动），按错误信息判断
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "deadlock") ||
+		strings.Contains(msg, "could not serialize access") ||
+		strings.Contains(msg, "database is locked")
+}
+
+func runTransactionWithRetry(ctx context.Context, db *gorm.DB, fn TxCtxFunc, options *txOptions) (*TxContext, error) {
+	policy := *options.retry
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryableError
+	}
+
+	for attempt := 1; ; attempt++ {
+		txCtx, err := runTransaction(ctx, db, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return txCtx, err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 第 attempt 次失败后的等待时间，指数退避并在 [d/2, d] 之间随机抖动
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,19 @@
+//go:build cgo
+
+package sqls
+
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	isSQLiteBusy = func(err error) (bool, bool) {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) {
+			return sqliteErr.Code == sqlite3.ErrBusy, true
+		}
+		return false, false
+	}
+}
@@ -1,10 +1,17 @@
 package sqls_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/YspCoder/simple/sqls"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -185,3 +192,332 @@ func TestWithTransaction_Concurrent(t *testing.T) {
 		<-done
 	}
 }
+
+func countTestUsers(t *testing.T, name string) int64 {
+	var count int64
+	assert.NoError(t, sqls.DB().Model(&TestUser{}).Where("name = ?", name).Count(&count).Error)
+	return count
+}
+
+// 测试上下文传播：嵌套调用默认加入外层事务，回调在外层事务提交后执行
+func TestWithTransactionCtx_Required(t *testing.T) {
+	setupTestDB(t)
+	var calls []string
+
+	err := sqls.WithTransactionCtx(context.Background(), func(ctx context.Context, outer *sqls.TxContext) error {
+		outer.RegisterCallback(func() { calls = append(calls, "outer") })
+
+		return sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+			assert.Same(t, outer, inner, "应该加入外层事务")
+			assert.Same(t, outer.Tx, sqls.DBFrom(ctx))
+			inner.RegisterCallback(func() { calls = append(calls, "inner") })
+			assert.Empty(t, calls, "外层事务提交前回调不应该被执行")
+			return inner.Tx.Create(&TestUser{Name: "required"}).Error
+		})
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+	assert.Equal(t, int64(1), countTestUsers(t, "required"))
+}
+
+// 测试加入外层事务时，内层失败导致整个事务回滚
+func TestWithTransactionCtx_RequiredRollback(t *testing.T) {
+	setupTestDB(t)
+
+	err := sqls.WithTransactionCtx(context.Background(), func(ctx context.Context, outer *sqls.TxContext) error {
+		if err := outer.Tx.Create(&TestUser{Name: "outer"}).Error; err != nil {
+			return err
+		}
+		return sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+			return errors.New("inner failed")
+		})
+	})
+
+	assert.Error(t, err)
+	assert.Zero(t, countTestUsers(t, "outer"))
+}
+
+// 测试保存点：内层失败只回滚到保存点，内层回调被丢弃
+func TestWithTransactionCtx_Nested(t *testing.T) {
+	setupTestDB(t)
+	var calls []string
+
+	err := sqls.WithTransactionCtx(context.Background(), func(ctx context.Context, outer *sqls.TxContext) error {
+		if err := outer.Tx.Create(&TestUser{Name: "outer"}).Error; err != nil {
+			return err
+		}
+		outer.RegisterCallback(func() { calls = append(calls, "outer") })
+
+		err := sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+			assert.NotSame(t, outer, inner)
+			inner.RegisterCallback(func() { calls = append(calls, "failed") })
+			if err := sqls.DBFrom(ctx).Create(&TestUser{Name: "failed"}).Error; err != nil {
+				return err
+			}
+			return errors.New("inner failed")
+		}, sqls.WithPropagation(sqls.PropagationNested))
+		assert.Error(t, err)
+
+		return sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+			inner.RegisterCallback(func() { calls = append(calls, "nested") })
+			return inner.Tx.Create(&TestUser{Name: "nested"}).Error
+		}, sqls.WithPropagation(sqls.PropagationNested))
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer", "nested"}, calls)
+	assert.Equal(t, int64(1), countTestUsers(t, "outer"))
+	assert.Equal(t, int64(1), countTestUsers(t, "nested"))
+	assert.Zero(t, countTestUsers(t, "failed"))
+}
+
+// 测试新建独立事务：外层回滚不影响已提交的内层事务
+func TestWithTransactionCtx_RequiresNew(t *testing.T) {
+	db := openFileDB(t, "tx.db")
+	sqls.SetDB(db)
+	innerCommitted := false
+
+	err := sqls.WithTransactionCtx(context.Background(), func(ctx context.Context, outer *sqls.TxContext) error {
+		err := sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+			assert.NotSame(t, outer, inner)
+			inner.RegisterCallback(func() { innerCommitted = true })
+			return inner.Tx.Create(&CndUser{Name: "requires_new"}).Error
+		}, sqls.WithPropagation(sqls.PropagationRequiresNew))
+		assert.NoError(t, err)
+		assert.True(t, innerCommitted, "内层事务提交后回调应该立即执行")
+
+		if err := outer.Tx.Create(&CndUser{Name: "outer"}).Error; err != nil {
+			return err
+		}
+		return errors.New("outer failed")
+	})
+
+	assert.Error(t, err)
+	var names []string
+	assert.NoError(t, db.Model(&CndUser{}).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"requires_new"}, names)
+}
+
+// 测试外层事务属于其他数据库时，WithTxDB 指定的数据库上新建事务而不是加入外层事务
+func TestWithTransactionCtx_OtherDB(t *testing.T) {
+	db := openFileDB(t, "default.db")
+	reporting := openFileDB(t, "reporting.db")
+	sqls.SetDB(db)
+
+	for _, propagation := range []sqls.Propagation{sqls.PropagationRequired, sqls.PropagationNested} {
+		err := sqls.WithTransactionCtx(context.Background(), func(ctx context.Context, outer *sqls.TxContext) error {
+			return sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+				assert.NotSame(t, outer, inner)
+				return sqls.DBFrom(ctx).Create(&CndUser{Name: "report"}).Error
+			}, sqls.WithTxDB(reporting), sqls.WithPropagation(propagation))
+		})
+		assert.NoError(t, err)
+	}
+
+	assert.Empty(t, userNames(t, db))
+	assert.Equal(t, []string{"report", "report"}, userNames(t, reporting))
+
+	// 同一数据库的其他会话仍加入外层事务
+	err := sqls.WithTransactionCtx(context.Background(), func(ctx context.Context, outer *sqls.TxContext) error {
+		return sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+			assert.Same(t, outer, inner)
+			return nil
+		}, sqls.WithTxDB(db.WithContext(ctx)))
+	})
+	assert.NoError(t, err)
+}
+
+// 测试没有事务时 DBFrom 返回 DB()
+func TestDBFrom(t *testing.T) {
+	setupTestDB(t)
+	assert.Same(t, sqls.DB().Config.ConnPool, sqls.DBFrom(context.Background()).Config.ConnPool)
+	_, ok := sqls.TxFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+// 测试可重试错误：失败的尝试被回滚，回调只在最终成功后执行一次
+func TestWithTransaction_Retry(t *testing.T) {
+	setupTestDB(t)
+	attempts, callbacks := 0, 0
+	policy := sqls.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	err := sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		attempts++
+		ctx.RegisterCallback(func() { callbacks++ })
+		if err := ctx.Tx.Create(&TestUser{Name: "retry"}).Error; err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return fmt.Errorf("create order: %w", sqlite3.Error{Code: sqlite3.ErrBusy})
+		}
+		return nil
+	}, sqls.WithRetry(policy))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 1, callbacks)
+	assert.Equal(t, int64(1), countTestUsers(t, "retry"))
+
+	// 超过最大次数或不可重试的错误直接返回
+	attempts = 0
+	err = sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		attempts++
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	}, sqls.WithRetry(policy))
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		attempts++
+		return errors.New("insufficient balance")
+	}, sqls.WithRetry(policy))
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+// codeError 带错误码的业务错误
+type codeError int
+
+func (e codeError) Error() string {
+	return "business error"
+}
+
+func (e codeError) Code() int {
+	return int(e)
+}
+
+// 测试可重试错误的识别
+func TestIsRetryableError(t *testing.T) {
+	assert.True(t, sqls.IsRetryableError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"}))
+	assert.False(t, sqls.IsRetryableError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+	assert.True(t, sqls.IsRetryableError(fmt.Errorf("update: %w", &pgconn.PgError{Code: "40001"})))
+	assert.True(t, sqls.IsRetryableError(&pgconn.PgError{Code: "40P01"}))
+	assert.False(t, sqls.IsRetryableError(&pgconn.PgError{Code: "23505"}))
+	assert.True(t, sqls.IsRetryableError(sqlite3.Error{Code: sqlite3.ErrBusy}))
+	assert.False(t, sqls.IsRetryableError(fmt.Errorf("pay: %w", codeError(5))), "业务错误码与 SQLITE_BUSY 相同时不重试")
+	assert.False(t, sqls.IsRetryableError(errors.New("record not found")))
+	assert.False(t, sqls.IsRetryableError(nil))
+}
+
+// 测试提交前钩子返回错误时事务回滚，并执行回滚钩子
+func TestWithTransaction_BeforeCommit(t *testing.T) {
+	setupTestDB(t)
+	var rollbackErr error
+	committed := false
+
+	err := sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		ctx.BeforeCommit(func() error {
+			var count int64
+			ctx.Tx.Model(&TestUser{}).Count(&count)
+			if count > 1 {
+				return errors.New("too many users")
+			}
+			return nil
+		})
+		ctx.AfterRollback(func(err error) { rollbackErr = err })
+		ctx.RegisterCallback(func() { committed = true })
+		return ctx.Tx.Create(&[]TestUser{{Name: "a"}, {Name: "b"}}).Error
+	})
+
+	assert.EqualError(t, err, "too many users")
+	assert.Equal(t, err, rollbackErr)
+	assert.False(t, committed)
+	assert.Zero(t, countTestUsers(t, "a"))
+}
+
+// 测试保存点回滚时执行保存点内的回滚钩子，外层事务正常提交
+func TestWithTransaction_NestedAfterRollback(t *testing.T) {
+	setupTestDB(t)
+	var rollbacks []string
+
+	err := sqls.WithTransactionCtx(context.Background(), func(ctx context.Context, outer *sqls.TxContext) error {
+		outer.AfterRollback(func(err error) { rollbacks = append(rollbacks, "outer") })
+		_ = sqls.WithTransactionCtx(ctx, func(ctx context.Context, inner *sqls.TxContext) error {
+			inner.AfterRollback(func(err error) { rollbacks = append(rollbacks, "inner") })
+			return errors.New("inner failed")
+		}, sqls.WithPropagation(sqls.PropagationNested))
+		return outer.Tx.Create(&TestUser{Name: "outer"}).Error
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"inner"}, rollbacks)
+}
+
+// 测试提交后回调的 panic 与错误被收集，不影响其他回调及已提交的数据
+func TestWithTransaction_AfterCommitErrors(t *testing.T) {
+	setupTestDB(t)
+	var (
+		executed    []int
+		callbackErr *sqls.TxCallbackError
+	)
+
+	err := sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		ctx.RegisterCallback(func() { panic("boom") })
+		ctx.AfterCommit(func() error { return errors.New("notify failed") })
+		ctx.RegisterCallback(func() { executed = append(executed, 3) })
+		return ctx.Tx.Create(&TestUser{Name: "committed"}).Error
+	}, sqls.WithCallbackErrorHandler(func(err *sqls.TxCallbackError) { callbackErr = err }))
+
+	assert.NoError(t, err, "事务已提交，回调的错误不作为事务的错误返回")
+	if assert.NotNil(t, callbackErr) {
+		assert.Len(t, callbackErr.Errors, 2)
+		assert.ErrorContains(t, callbackErr, "boom")
+		assert.ErrorContains(t, callbackErr, "notify failed")
+	}
+	assert.Equal(t, []int{3}, executed)
+	assert.Equal(t, int64(1), countTestUsers(t, "committed"))
+}
+
+// 测试提交后回调返回可重试的错误时不会重新执行已提交的事务
+func TestWithTransaction_RetryCallbackError(t *testing.T) {
+	setupTestDB(t)
+	var (
+		attempts, callbacks int
+		callbackErr         *sqls.TxCallbackError
+	)
+
+	err := sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+		attempts++
+		ctx.AfterCommit(func() error {
+			callbacks++
+			return errors.New("notify: database is locked")
+		})
+		return ctx.Tx.Create(&TestUser{Name: "once"}).Error
+	}, sqls.WithRetry(sqls.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		sqls.WithCallbackErrorHandler(func(err *sqls.TxCallbackError) { callbackErr = err }))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 1, callbacks)
+	assert.ErrorContains(t, callbackErr, "database is locked")
+	assert.Equal(t, int64(1), countTestUsers(t, "once"))
+}
+
+// 测试在协程池中异步执行提交后回调
+func TestWithTransaction_AsyncCallbacks(t *testing.T) {
+	setupTestDB(t)
+	pool := sqls.NewCallbackPool(2, 10)
+	var (
+		mu     sync.Mutex
+		orders []int
+	)
+
+	for i := 0; i < 5; i++ {
+		err := sqls.WithTransaction(func(ctx *sqls.TxContext) error {
+			ctx.RegisterCallback(func() { panic("boom") })
+			ctx.RegisterCallback(func() {
+				mu.Lock()
+				defer mu.Unlock()
+				orders = append(orders, i)
+			})
+			return ctx.Tx.Create(&TestUser{Name: "async"}).Error
+		}, sqls.WithAsyncCallbacks(pool))
+		assert.NoError(t, err, "异步回调的错误不返回给调用方")
+	}
+	pool.Close()
+
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4}, orders)
+	assert.Equal(t, int64(5), countTestUsers(t, "async"))
+}
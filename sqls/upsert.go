@@ -0,0 +1,64 @@
+package sqls
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpsertResult 批量 upsert 的结果
+type UpsertResult struct {
+	Affected int64 // 驱动返回的影响行数
+	Inserted int64 // 新增的行数，CountsKnown 为 false 时为 0
+	Updated  int64 // 更新的行数，CountsKnown 为 false 时为 0
+	// CountsKnown 驱动是否能区分新增与更新的行数，目前只有 MySQL 支持
+	CountsKnown bool
+}
+
+// Upsert 批量插入，conflictCols 冲突时更新 updateCols，updateCols 为空时更新全部列。
+// 按方言生成 ON DUPLICATE KEY UPDATE（MySQL，冲突判断使用表上的全部唯一索引，忽略 conflictCols）
+// 或 ON CONFLICT ... DO UPDATE（Postgres、SQLite），并按方言的占位符数量上限分批执行，
+// 分批时在同一事务中执行
+func Upsert[T any](db *gorm.DB, rows []T, conflictCols []string, updateCols []string) (*UpsertResult, error) {
+	result := &UpsertResult{}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	onConflict := clause.OnConflict{UpdateAll: len(updateCols) == 0}
+	for _, column := range conflictCols {
+		if err := ValidateIdentifier(column); err != nil {
+			return result, err
+		}
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+	if len(updateCols) > 0 {
+		for _, column := range updateCols {
+			if err := ValidateIdentifier(column); err != nil {
+				return result, err
+			}
+		}
+		onConflict.DoUpdates = clause.AssignmentColumns(updateCols)
+	}
+
+	batchSize, err := upsertBatchSize(db, new(T))
+	if err != nil {
+		return result, err
+	}
+	tx := db.Clauses(onConflict).CreateInBatches(rows, batchSize)
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+
+	result.Affected = tx.RowsAffected
+	result.Inserted, result.Updated, result.CountsKnown = DialectOf(db).UpsertCounts(int64(len(rows)), tx.RowsAffected)
+	return result, nil
+}
+
+// upsertBatchSize 每批的行数，保证每批的占位符数量不超过方言的上限
+func upsertBatchSize(db *gorm.DB, model interface{}) (int, error) {
+	columns, err := ModelColumns(db, model)
+	if err != nil {
+		return 0, err
+	}
+	return max(DialectOf(db).MaxPlaceholders()/max(len(columns), 1), 1), nil
+}
@@ -0,0 +1,71 @@
+package sqls_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/stretchr/testify/assert"
+)
+
+type UpsertItem struct {
+	ID    int64  `gorm:"primarykey"`
+	Code  string `gorm:"uniqueIndex"`
+	Name  string
+	Price int
+}
+
+func makeUpsertItems(n int, price int) []UpsertItem {
+	items := make([]UpsertItem, n)
+	for i := range items {
+		items[i] = UpsertItem{Code: fmt.Sprintf("sku-%05d", i), Name: fmt.Sprintf("item %d", i), Price: price}
+	}
+	return items
+}
+
+// 测试批量 upsert，数据量超过 SQLite 占位符上限时分批执行
+func TestUpsert(t *testing.T) {
+	db := setupCndTestDB(t)
+	assert.NoError(t, db.AutoMigrate(&UpsertItem{}))
+
+	ret, err := sqls.Upsert(db, makeUpsertItems(10000, 1), []string{"code"}, []string{"price"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10000), ret.Affected)
+	assert.False(t, ret.CountsKnown)
+
+	items := makeUpsertItems(12000, 2)
+	items[0].Name = "renamed"
+	ret, err = sqls.Upsert(db, items, []string{"code"}, []string{"price"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12000), ret.Affected)
+
+	var count, updated int64
+	db.Model(&UpsertItem{}).Count(&count)
+	db.Model(&UpsertItem{}).Where("price = ?", 2).Count(&updated)
+	assert.Equal(t, int64(12000), count)
+	assert.Equal(t, int64(12000), updated)
+
+	var first UpsertItem
+	db.Where("code = ?", "sku-00000").Take(&first)
+	assert.Equal(t, "item 0", first.Name, "只更新 updateCols 指定的列")
+
+	// updateCols 为空时更新全部列
+	_, err = sqls.Upsert(db, items[:1], []string{"code"}, nil)
+	assert.NoError(t, err)
+	db.Where("code = ?", "sku-00000").Take(&first)
+	assert.Equal(t, "renamed", first.Name)
+
+	_, err = sqls.Upsert(db, items[:1], []string{"code; DROP TABLE upsert_items"}, nil)
+	assert.ErrorIs(t, err, sqls.ErrInvalidColumn)
+}
+
+// 测试 MySQL 根据影响行数计算新增、更新的行数
+func TestDialect_UpsertCounts(t *testing.T) {
+	inserted, updated, ok := sqls.MySQLDialect{}.UpsertCounts(5, 7)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), inserted)
+	assert.Equal(t, int64(2), updated)
+
+	_, _, ok = sqls.PostgresDialect{}.UpsertCounts(5, 5)
+	assert.False(t, ok)
+}
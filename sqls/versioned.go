@@ -0,0 +1,56 @@
+package sqls
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// VersionColumn 乐观锁版本号列名
+const VersionColumn = "version"
+
+// ErrStaleObject 乐观锁更新失败：数据已被其他请求修改（或已删除）
+var ErrStaleObject = errors.New("sqls: stale object")
+
+// Versioned 乐观锁版本号，与 GormModel 一起嵌入模型，使用 UpdatesVersioned 更新时校验并递增版本号
+type Versioned struct {
+	Version int64 `gorm:"not null;default:0" json:"version" form:"version"`
+}
+
+// UpdatesVersioned 更新符合条件且版本号等于 version 的数据，同时将版本号加一，
+// 没有数据被更新时返回 ErrStaleObject；与 Updates 一样，未设置条件且未调用 AllowGlobal 时返回 gorm.ErrMissingWhereClause
+func (s *Cnd) UpdatesVersioned(db *gorm.DB, model interface{}, version int64, values map[string]interface{}) error {
+	if s.Error != nil {
+		return s.Error
+	}
+	if len(s.Params) == 0 && !s.Global {
+		return gorm.ErrMissingWhereClause
+	}
+
+	tx, cancel := s.withContext(db.Statement.Context, db)
+	defer cancel()
+	return updatesVersioned(s.buildWhere(tx.Model(model)), version, values)
+}
+
+// UpdatesVersioned 根据主键及版本号更新多个字段，同时将版本号加一，没有数据被更新时返回 ErrStaleObject
+func (r *Repo[T]) UpdatesVersioned(id interface{}, version int64, values map[string]interface{}) error {
+	return updatesVersioned(r.DB().Model(new(T)).Where(primaryKeyEq(id)), version, values)
+}
+
+func updatesVersioned(db *gorm.DB, version int64, values map[string]interface{}) error {
+	columns := make(map[string]interface{}, len(values)+1)
+	for column, value := range values {
+		columns[column] = value
+	}
+	versionColumn := wrapKeyword(DialectOf(db), VersionColumn)
+	columns[VersionColumn] = gorm.Expr(versionColumn+" + ?", 1)
+
+	ret := db.Where(versionColumn+" = ?", version).Updates(columns)
+	if ret.Error != nil {
+		return ret.Error
+	}
+	if ret.RowsAffected == 0 {
+		return ErrStaleObject
+	}
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"strconv"
 )
 
+// CodeConflict 数据冲突，例如乐观锁更新失败（sqls.ErrStaleObject）
+const CodeConflict = 409
+
 func NewError(code int, text string) *CodeError {
 	return &CodeError{code, text, nil}
 }
@@ -72,6 +72,14 @@ func JsonError(err error) *JsonResult {
 			Success: false,
 		}
 	}
+	if errors.Is(err, sqls.ErrStaleObject) {
+		return &JsonResult{
+			Code:    CodeConflict,
+			Msg:     "数据已被修改，请刷新后重试",
+			Data:    nil,
+			Success: false,
+		}
+	}
 	return &JsonResult{
 		Code:    0,
 		Msg:     err.Error(),
@@ -0,0 +1,20 @@
+package web_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/YspCoder/simple/web"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试乐观锁更新失败映射为数据冲突错误码
+func TestJsonError_StaleObject(t *testing.T) {
+	ret := web.JsonError(fmt.Errorf("update article: %w", sqls.ErrStaleObject))
+	assert.Equal(t, web.CodeConflict, ret.Code)
+	assert.False(t, ret.Success)
+
+	ret = web.JsonError(web.NewError(1001, "参数错误"))
+	assert.Equal(t, 1001, ret.Code)
+}
@@ -295,3 +295,12 @@ func GetPaging(ctx iris.Context) *sqls.Paging {
 	}
 	return &sqls.Paging{Page: page, Limit: limit}
 }
+
+// GetCursorPaging 从请求中获取游标分页参数：cursor、limit
+func GetCursorPaging(ctx iris.Context) *sqls.CursorPaging {
+	limit := FormValueIntDefault(ctx, "limit", 20)
+	if limit <= 0 {
+		limit = 20
+	}
+	return &sqls.CursorPaging{Cursor: FormValue(ctx, "cursor"), Limit: limit}
+}
@@ -1,6 +1,7 @@
 package params
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/YspCoder/simple/common/strs"
@@ -21,12 +22,20 @@ const (
 	In       QueryOp = "in"
 	Starting QueryOp = "starting"
 	Ending   QueryOp = "ending"
+
+	ILike     QueryOp = "ilike"     // 大小写不敏感的 Like
+	IStarting QueryOp = "istarting" // 大小写不敏感的 Starting
+	IEnding   QueryOp = "iending"   // 大小写不敏感的 Ending
+
+	Contains QueryOp = "contains" // JSON 数组包含，配合 JsonPath 使用
 )
 
 type QueryFilter struct {
 	ParamName    string                     // 请求参数名
 	Op           QueryOp                    // 操作符
 	ColumnName   string                     // 列名
+	Table        string                     // 列所属的表名或别名，联表查询时使用，例如：u
+	JsonPath     string                     // JSON 列中的路径，例如：address.city，设置后 Eq 按路径上的值过滤，Contains 按路径上的数组包含过滤
 	ValueWrapper func(origin string) string // Value修饰器，可以
 }
 
@@ -37,8 +46,23 @@ func NewPagedSqlCnd(ctx iris.Context, filters ...QueryFilter) *sqls.Cnd {
 	return cnd
 }
 
+// NewCursorSqlCnd 创建使用游标分页的查询条件，调用方需设置排序后通过 FindCursor 查询
+func NewCursorSqlCnd(ctx iris.Context, filters ...QueryFilter) *sqls.Cnd {
+	cnd := NewSqlCnd(ctx, filters...)
+	p := GetCursorPaging(ctx)
+	cnd.Cursor(p.Cursor, p.Limit)
+	return cnd
+}
+
 func NewSqlCnd(ctx iris.Context, filters ...QueryFilter) *sqls.Cnd {
-	cnd := sqls.NewCnd()
+	return ApplyQueryFilters(ctx, sqls.NewCnd(), filters...)
+}
+
+// ApplyQueryFilters 将请求参数按 filters 添加到 cnd 中，列名由参数名推导时会进行校验，
+// 可以配合 AllowModel 限制可查询的列，例如：
+// ApplyQueryFilters(ctx, sqls.NewCnd().AllowModel(sqls.DB(), &User{}), filters...)，
+// 非法列名会记录在 cnd.Error 中，执行查询时返回
+func ApplyQueryFilters(ctx iris.Context, cnd *sqls.Cnd, filters ...QueryFilter) *sqls.Cnd {
 	for _, filter := range filters {
 		var (
 			columnName = filter.ColumnName
@@ -56,7 +80,18 @@ func NewSqlCnd(ctx iris.Context, filters ...QueryFilter) *sqls.Cnd {
 		if strs.IsBlank(columnName) {
 			columnName = strcase.ToSnake(filter.ParamName)
 		}
-		if filter.Op == Eq {
+		if strs.IsNotBlank(filter.Table) {
+			columnName = filter.Table + "." + columnName
+		}
+		if strs.IsNotBlank(filter.JsonPath) {
+			if filter.Op == Eq {
+				cnd.JsonEq(columnName, filter.JsonPath, paramValue)
+			} else if filter.Op == Contains {
+				cnd.JsonArrayContains(columnName, filter.JsonPath, paramValue)
+			} else {
+				cnd.AddError(fmt.Errorf("unsupported json filter op '%s'", filter.Op))
+			}
+		} else if filter.Op == Eq {
 			cnd.Eq(columnName, paramValue)
 		} else if filter.Op == Gt {
 			cnd.Gt(columnName, paramValue)
@@ -72,6 +107,12 @@ func NewSqlCnd(ctx iris.Context, filters ...QueryFilter) *sqls.Cnd {
 			cnd.Starting(columnName, paramValue)
 		} else if filter.Op == Ending {
 			cnd.Ending(columnName, paramValue)
+		} else if filter.Op == ILike {
+			cnd.ILike(columnName, paramValue)
+		} else if filter.Op == IStarting {
+			cnd.IStarting(columnName, paramValue)
+		} else if filter.Op == IEnding {
+			cnd.IEnding(columnName, paramValue)
 		} else if filter.Op == In {
 			ss := strings.Split(paramValue, ",")
 			cnd.In(columnName, ss)
@@ -81,6 +81,14 @@ func (q *QueryParams) LikeByReq(column string) *QueryParams {
 	return q
 }
 
+func (q *QueryParams) ILikeByReq(column string) *QueryParams {
+	value := q.getValueByColumn(column)
+	if len(value) > 0 {
+		q.ILike(column, value)
+	}
+	return q
+}
+
 func (q *QueryParams) PageByReq() *QueryParams {
 	if q.Ctx == nil {
 		return q
@@ -90,13 +98,36 @@ func (q *QueryParams) PageByReq() *QueryParams {
 	return q
 }
 
+func (q *QueryParams) CursorByReq() *QueryParams {
+	if q.Ctx == nil {
+		return q
+	}
+	paging := GetCursorPaging(q.Ctx)
+	q.Cursor(paging.Cursor, paging.Limit)
+	return q
+}
+
+// SortByReq 按请求中的排序参数排序，见 GetSort，解析失败时错误记录在 Cnd.Error 中
+func (q *QueryParams) SortByReq(allowed map[string]string) *QueryParams {
+	if q.Ctx == nil {
+		return q
+	}
+	orders, err := GetSort(q.Ctx, allowed)
+	if err != nil {
+		q.AddError(err)
+		return q
+	}
+	q.OrderBy(orders...)
+	return q
+}
+
 func (q *QueryParams) Asc(column string) *QueryParams {
-	q.Orders = append(q.Orders, sqls.OrderByCol{Column: column, Asc: true})
+	q.Cnd.Asc(column)
 	return q
 }
 
 func (q *QueryParams) Desc(column string) *QueryParams {
-	q.Orders = append(q.Orders, sqls.OrderByCol{Column: column, Asc: false})
+	q.Cnd.Desc(column)
 	return q
 }
 
@@ -0,0 +1,81 @@
+package params
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YspCoder/simple/common/strs"
+	"github.com/YspCoder/simple/common/strs/strcase"
+	"github.com/YspCoder/simple/sqls"
+	"github.com/kataras/iris/v12"
+)
+
+// GetSort 从请求中解析排序参数，支持以下两种形式：
+//
+//	sort=-createTime,name          字段前加 - 表示倒序，加 + 或不加表示正序
+//	orderBy=createTime&order=desc  order 可选 asc、desc，默认 asc
+//
+// allowed 为 API 字段名到列名的映射，列名为空时使用字段名的蛇形形式，不在 allowed 中的字段返回错误
+func GetSort(ctx iris.Context, allowed map[string]string) ([]sqls.OrderByCol, error) {
+	if sort := strings.TrimSpace(ctx.FormValue("sort")); sort != "" {
+		var orders []sqls.OrderByCol
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			asc := true
+			if strings.HasPrefix(field, "-") {
+				asc = false
+				field = field[1:]
+			} else if strings.HasPrefix(field, "+") {
+				field = field[1:]
+			}
+			order, err := sortColumn(field, asc, allowed)
+			if err != nil {
+				return nil, err
+			}
+			orders = append(orders, order)
+		}
+		return orders, nil
+	}
+
+	orderBy := strings.TrimSpace(ctx.FormValue("orderBy"))
+	if orderBy == "" {
+		return nil, nil
+	}
+	asc := true
+	switch strings.ToLower(strings.TrimSpace(ctx.FormValue("order"))) {
+	case "", "asc":
+	case "desc":
+		asc = false
+	default:
+		return nil, fmt.Errorf("unsupported sort order '%s'", ctx.FormValue("order"))
+	}
+	var orders []sqls.OrderByCol
+	for _, field := range strings.Split(orderBy, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		order, err := sortColumn(field, asc, allowed)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+func sortColumn(field string, asc bool, allowed map[string]string) (sqls.OrderByCol, error) {
+	column, ok := allowed[field]
+	if !ok {
+		return sqls.OrderByCol{}, fmt.Errorf("unsupported sort field '%s'", field)
+	}
+	if strs.IsBlank(column) {
+		column = strcase.ToSnake(field)
+	}
+	if err := sqls.ValidateIdentifier(column); err != nil {
+		return sqls.OrderByCol{}, err
+	}
+	return sqls.OrderByCol{Column: column, Asc: asc}, nil
+}
@@ -0,0 +1,65 @@
+package params_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/YspCoder/simple/sqls"
+	"github.com/YspCoder/simple/web/params"
+	"github.com/kataras/iris/v12"
+	"github.com/stretchr/testify/assert"
+)
+
+var sortAllowed = map[string]string{
+	"createTime": "",
+	"name":       "u.name",
+	"id":         "id",
+}
+
+func getSort(t *testing.T, query string) ([]sqls.OrderByCol, error) {
+	app := iris.New()
+	ctx := app.ContextPool.Acquire(httptest.NewRecorder(), httptest.NewRequest("GET", "/?"+query, nil))
+	defer app.ContextPool.Release(ctx)
+	return params.GetSort(ctx, sortAllowed)
+}
+
+// 测试 sort 参数的 -、+ 前缀及蛇形列名
+func TestGetSort_Sort(t *testing.T) {
+	orders, err := getSort(t, "sort=-createTime,%2Bname,id")
+	assert.NoError(t, err)
+	assert.Equal(t, []sqls.OrderByCol{
+		{Column: "create_time", Asc: false},
+		{Column: "u.name", Asc: true},
+		{Column: "id", Asc: true},
+	}, orders)
+
+	orders, err = getSort(t, "")
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+// 测试 orderBy、order 参数
+func TestGetSort_OrderBy(t *testing.T) {
+	orders, err := getSort(t, "orderBy=createTime,id&order=DESC")
+	assert.NoError(t, err)
+	assert.Equal(t, []sqls.OrderByCol{{Column: "create_time", Asc: false}, {Column: "id", Asc: false}}, orders)
+
+	orders, err = getSort(t, "orderBy=name")
+	assert.NoError(t, err)
+	assert.Equal(t, []sqls.OrderByCol{{Column: "u.name", Asc: true}}, orders)
+
+	_, err = getSort(t, "orderBy=name&order=random()")
+	assert.Error(t, err)
+}
+
+// 测试不在 allowed 中的字段
+func TestGetSort_NotAllowed(t *testing.T) {
+	_, err := getSort(t, "sort=password")
+	assert.Error(t, err)
+
+	_, err = getSort(t, "sort=-id,nickname")
+	assert.Error(t, err)
+
+	_, err = getSort(t, "orderBy=password&order=asc")
+	assert.Error(t, err)
+}